@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"datflux/internal/password"
+	"datflux/internal/ui"
+)
+
+// `datflux breach index SOURCE...`, everything else about breaches
+// happens in the TUI and check once the index exists
+func breachCommand(args []string) {
+	if len(args) == 0 || args[0] != "index" {
+		exitWithError(fmt.Errorf("usage: datflux breach index [--out PATH] SOURCE..."))
+	}
+
+	var out string
+	fs := newFlagSet("breach index")
+	fs.StringVar(&out, "out", password.BreachIndexPath(), "where to write the index")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printHelp()
+			return
+		}
+		exitWithError(err)
+	}
+	if fs.NArg() == 0 {
+		exitWithError(fmt.Errorf("breach index needs a HIBP SHA-1 file or range directory"))
+	}
+
+	ui.InitializeStyles(ui.GetDefaultTheme())
+
+	written, err := writeBreachIndex(out, fs.Args())
+	if err != nil {
+		exitWithError(err)
+	}
+	fmt.Fprintln(os.Stderr, ui.ValueStyle.Render(fmt.Sprintf("Indexed %d hashes to %s", written, out)))
+}
+
+// built next to the destination and renamed, a failed run keeps the old index
+func writeBreachIndex(path string, sources []string) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return 0, err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".hibp-*.idx")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file.Name())
+
+	written, err := password.BuildBreachIndex(file, sources)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return written, err
+	}
+	return written, os.Rename(file.Name(), path)
+}
+
+// offline breach lookups shared by the TUI and check
+type breachFlags struct {
+	index string
+}
+
+func (bf *breachFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&bf.index, "breach-index", "", "index from `datflux breach index` (default "+password.BreachIndexPath()+" if present)")
+}
+
+// nil without an error when no index was asked for and none was built
+func (bf *breachFlags) open() (*password.BreachIndex, error) {
+	if bf.index != "" {
+		return password.OpenBreachIndex(bf.index)
+	}
+
+	index, err := password.OpenBreachIndex(password.BreachIndexPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return index, err
+}
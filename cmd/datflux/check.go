@@ -0,0 +1,449 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"datflux/internal/password"
+	"datflux/internal/ui"
+
+	"golang.org/x/term"
+)
+
+// exit statuses scripts can gate on, 1 means at least one password failed
+const checkErrorStatus = 2
+
+// one audited password, the password itself only with --show
+type checkResult struct {
+	Line       int         `json:"line"`
+	Password   string      `json:"password,omitempty"`
+	Score      int         `json:"score"`
+	Entropy    float64     `json:"entropy_bits"`
+	CrackTimes []crackTime `json:"crack_times"`
+	UserInputs []string    `json:"user_inputs,omitempty"` // found in the password, only with --show
+	InputCount int         `json:"user_input_count"`
+	Penalty    float64     `json:"user_input_penalty_bits"`
+	Breached   *int        `json:"breach_count"` // null without a breach index
+	Quantum    *quantumFit `json:"quantum"`      // null without quantum models
+	Violations []string    `json:"violations"`
+	Failures   []string    `json:"failures"` // violations plus the score gate
+	Pass       bool        `json:"pass"`
+}
+
+type crackTime struct {
+	Model   string   `json:"model"`
+	Seconds *float64 `json:"seconds"` // null when too large to represent
+	Display string   `json:"display"`
+
+	// 50% chance, null for models not bought by the GPU-hour or too large
+	CostUSD         *float64 `json:"cost_usd"`
+	EnergyKWh       *float64 `json:"energy_kwh"`
+	HashesPerDollar *float64 `json:"hashes_per_dollar"`
+	CostDisplay     string   `json:"cost_display,omitempty"`
+}
+
+// against the most demanding quantum model, the length is in random
+// characters from the classes the password already uses
+type quantumFit struct {
+	Model             string  `json:"model"`
+	TargetYears       float64 `json:"target_years"`
+	RequiredBits      float64 `json:"required_bits"`
+	Safe              bool    `json:"safe"`
+	RecommendedLength int     `json:"recommended_length"`
+}
+
+type checkOptions struct {
+	minScore int
+	json     bool
+	show     bool
+	models   []password.AttackModel
+}
+
+// audits passwords from a file or stdin, one per line, against zxcvbn,
+// every attack model, pwquality.conf and optionally a policy or profile
+// passwords are never echoed or printed unless --show is given
+func checkPasswords(args []string) {
+	var opts checkOptions
+	var pf policyFlags
+	var uf userInputFlags
+	var bf breachFlags
+	var blf blacklistFlags
+	var amf attackModelFlags
+	var profileName string
+	fs := newFlagSet("check")
+	pf.register(fs)
+	uf.register(fs)
+	bf.register(fs)
+	blf.register(fs)
+	amf.register(fs)
+	fs.StringVar(&profileName, "profile", "", "character profile whose policy passwords must pass")
+	fs.IntVar(&opts.minScore, "min-score", 3, "fail passwords zxcvbn scores below this (0-4, 0 turns the gate off)")
+	fs.BoolVar(&opts.json, "json", false, "print a JSON report")
+	fs.BoolVar(&opts.show, "show", false, "include the passwords in the report")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printHelp()
+			return
+		}
+		exitCheckError(err)
+	}
+	if fs.NArg() > 1 {
+		exitCheckError(fmt.Errorf("check reads one file, got %d", fs.NArg()))
+	}
+	if opts.minScore < 0 || opts.minScore > 4 {
+		exitCheckError(fmt.Errorf("--min-score must be between 0 and 4 (got %d)", opts.minScore))
+	}
+
+	ui.InitializeStyles(ui.GetDefaultTheme())
+
+	userInputs, err := uf.inputs()
+	if err != nil {
+		exitCheckError(err)
+	}
+	if opts.models, err = amf.load(); err != nil {
+		exitCheckError(err)
+	}
+
+	rules, profileInputs, err := checkRules(&pf, profileName)
+	if err != nil {
+		exitCheckError(err)
+	}
+	zxcvbn := password.ZxcvbnAnalyzer{UserInputs: append(userInputs, profileInputs...)}
+
+	candidates, err := readCandidates(fs.Arg(0))
+	if err != nil {
+		exitCheckError(err)
+	}
+
+	analyzers := []password.Analyzer{zxcvbn, rules}
+	breachIndex, err := bf.open()
+	if err != nil {
+		exitCheckError(err)
+	}
+	if breachIndex != nil {
+		defer breachIndex.Close()
+		analyzers = append(analyzers, password.BreachAnalyzer{Index: breachIndex})
+		fmt.Fprintln(os.Stderr, ui.ValueStyle.Render(fmt.Sprintf("Breach index: %d hashes", breachIndex.Len())))
+	}
+
+	blacklist, err := blf.load()
+	if err != nil {
+		exitCheckError(err)
+	}
+	if blacklist != nil {
+		analyzers = append(analyzers, password.BlacklistAnalyzer{Blacklist: blacklist})
+		fmt.Fprintln(os.Stderr, ui.ValueStyle.Render(fmt.Sprintf("Blacklist: %d words", blacklist.Len())))
+	}
+
+	analyzer := password.NewCachedAnalyzer(password.Combine(analyzers...), analyzerCacheSize, nil)
+	results := auditPasswords(candidates, analyzer, opts)
+
+	if opts.json {
+		err = printCheckJSON(os.Stdout, results)
+	} else {
+		err = printCheckTable(os.Stdout, results, opts)
+	}
+	if err != nil {
+		exitCheckError(err)
+	}
+
+	failed := 0
+	for _, result := range results {
+		if !result.Pass {
+			failed++
+		}
+	}
+	fmt.Fprintln(os.Stderr, ui.ValueStyle.Render(fmt.Sprintf("%d checked, %d failed", len(results), failed)))
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// pwquality always applies, policy flags or a profile add their policy,
+// a profile's user inputs are returned for zxcvbn
+func checkRules(pf *policyFlags, profileName string) (password.RuleAnalyzer, []string, error) {
+	var rules password.RuleAnalyzer
+	var userInputs []string
+
+	quality, err := loadCheckRules(pf.pwquality)
+	if err != nil {
+		return rules, nil, err
+	}
+	rules.Quality = &quality
+	fmt.Fprintln(os.Stderr, ui.ValueStyle.Render("pwquality: "+quality.String()))
+
+	// pwquality is checked on its own, not folded into the policy
+	policyOnly := *pf
+	policyOnly.pwquality = ""
+
+	switch {
+	case profileName != "" && policyOnly.isSet():
+		return rules, nil, fmt.Errorf("--profile cannot be combined with policy flags")
+	case profileName != "":
+		profile, err := password.FindProfile(password.ProfilesPath(), profileName)
+		if err != nil {
+			return rules, nil, err
+		}
+		userInputs = profile.UserInputs
+		if profile.Mode() == password.ModeCharacters {
+			rules.Policy = &profile.Policy
+		} else {
+			fmt.Fprintln(os.Stderr, ui.WarningStyle.Render(
+				fmt.Sprintf("profile %q is a template, only its user inputs apply", profileName)))
+		}
+	case policyOnly.isSet():
+		policy, err := policyOnly.policy()
+		if err != nil {
+			return rules, nil, err
+		}
+		rules.Policy = &policy
+	}
+
+	if rules.Policy != nil {
+		fmt.Fprintln(os.Stderr, ui.ValueStyle.Render("Policy: "+rules.Policy.String()))
+	}
+	return rules, userInputs, nil
+}
+
+// an explicit path must exist, the system file falls back to libpwquality's defaults
+func loadCheckRules(path string) (password.PWQuality, error) {
+	if path != "" {
+		return password.LoadPWQuality(path)
+	}
+
+	quality, err := password.LoadPWQuality(password.PWQualityPath)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintln(os.Stderr, ui.WarningStyle.Render(
+			fmt.Sprintf("%s not found, using libpwquality defaults", password.PWQualityPath)))
+		return password.DefaultPWQuality(), nil
+	}
+	return quality, err
+}
+
+type candidate struct {
+	line     int
+	password string
+}
+
+// from a file, a pipe, or prompted without echo when stdin is a terminal
+func readCandidates(path string) ([]candidate, error) {
+	if path == "" || path == "-" {
+		if term.IsTerminal(int(os.Stdin.Fd())) {
+			return promptCandidates()
+		}
+		return scanCandidates(os.Stdin)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return scanCandidates(file)
+}
+
+func scanCandidates(r io.Reader) ([]candidate, error) {
+	var candidates []candidate
+	lineNumber := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line != "" {
+			candidates = append(candidates, candidate{lineNumber, line})
+		}
+	}
+	return candidates, scanner.Err()
+}
+
+// asks until an empty answer, typing isn't echoed
+func promptCandidates() ([]candidate, error) {
+	var candidates []candidate
+	for {
+		fmt.Fprint(os.Stderr, ui.ValueStyle.Render(
+			fmt.Sprintf("Password #%d (empty to finish): ", len(candidates)+1)))
+		line, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 {
+			return candidates, nil
+		}
+		candidates = append(candidates, candidate{len(candidates) + 1, string(line)})
+	}
+}
+
+func auditPasswords(candidates []candidate, analyzer password.Analyzer, opts checkOptions) []checkResult {
+	results := make([]checkResult, 0, len(candidates))
+
+	for _, candidate := range candidates {
+		strength := analyzer.Analyze(candidate.password)
+
+		result := checkResult{
+			Line:       candidate.line,
+			Score:      strength.Score,
+			Entropy:    strength.EntropyBits,
+			InputCount: len(strength.UserMatches),
+			Penalty:    strength.UserPenalty,
+			Violations: append([]string{}, strength.Violations...),
+		}
+		if strength.BreachChecked {
+			result.Breached = &strength.BreachCount
+		}
+		// the matches are pieces of the password
+		if opts.show {
+			result.Password = candidate.password
+			result.UserInputs = append([]string{}, strength.UserMatches...)
+		}
+
+		for _, model := range opts.models {
+			seconds := password.CrackSecondsForModel(strength, model)
+			crack := crackTime{Model: model.Name, Display: password.GetCrackTimeDescription(seconds)}
+			if !math.IsInf(seconds, 0) {
+				crack.Seconds = &seconds
+			}
+			if cost, ok := model.Cost(seconds); ok {
+				crack.CostDisplay = password.FormatDollars(cost.Dollars)
+				crack.HashesPerDollar = &cost.HashesPerDollar
+				if !math.IsInf(cost.Dollars, 0) {
+					crack.CostUSD, crack.EnergyKWh = &cost.Dollars, &cost.KWh
+				}
+			}
+			result.CrackTimes = append(result.CrackTimes, crack)
+		}
+
+		if quantum := strictestQuantumModel(opts.models); quantum != nil {
+			required := quantum.Quantum.RequiredBits()
+			result.Quantum = &quantumFit{
+				Model:             quantum.Name,
+				TargetYears:       quantum.Quantum.TargetYears,
+				RequiredBits:      required,
+				Safe:              strength.EntropyBits >= required,
+				RecommendedLength: password.RecommendedCharacters(candidate.password, required),
+			}
+		}
+
+		result.Failures = append([]string{}, result.Violations...)
+		if strength.Score < opts.minScore {
+			result.Failures = append(result.Failures,
+				fmt.Sprintf("score %d below %d", strength.Score, opts.minScore))
+		}
+		result.Pass = len(result.Failures) == 0
+
+		results = append(results, result)
+	}
+	return results
+}
+
+// the quantum model needing the most entropy, nil when there is none
+func strictestQuantumModel(models []password.AttackModel) *password.AttackModel {
+	var strictest *password.AttackModel
+	for i, model := range models {
+		if model.Quantum == nil {
+			continue
+		}
+		if strictest == nil || model.Quantum.RequiredBits() > strictest.Quantum.RequiredBits() {
+			strictest = &models[i]
+		}
+	}
+	return strictest
+}
+
+func printCheckJSON(w io.Writer, results []checkResult) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
+
+// one row per password, failing rows in the warning style
+func printCheckTable(w io.Writer, results []checkResult, opts checkOptions) error {
+	var table strings.Builder
+	tw := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+
+	header := []string{"LINE"}
+	if opts.show {
+		header = append(header, "PASSWORD")
+	}
+	header = append(header, "SCORE", "BITS")
+	for _, model := range opts.models {
+		header = append(header, strings.ToUpper(model.Name))
+	}
+	quantum := strictestQuantumModel(opts.models)
+	if quantum != nil {
+		header = append(header, fmt.Sprintf("QUANTUM-SAFE %gY", quantum.Quantum.TargetYears))
+	}
+	header = append(header, "RESULT")
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for _, result := range results {
+		row := []string{"#" + strconv.Itoa(result.Line)}
+		if opts.show {
+			row = append(row, result.Password)
+		}
+		row = append(row, fmt.Sprintf("%d/4", result.Score), fmt.Sprintf("%.1f", result.Entropy))
+		for _, crack := range result.CrackTimes {
+			if crack.CostDisplay != "" {
+				row = append(row, fmt.Sprintf("%s (%s)", crack.Display, crack.CostDisplay))
+			} else {
+				row = append(row, crack.Display)
+			}
+		}
+		if result.Quantum != nil {
+			if result.Quantum.Safe {
+				row = append(row, "yes")
+			} else {
+				row = append(row, fmt.Sprintf("no, %d+ chars", result.Quantum.RecommendedLength))
+			}
+		}
+		outcome := "pass"
+		if !result.Pass {
+			outcome = "FAIL: " + strings.Join(result.Failures, "; ")
+		}
+		switch {
+		case len(result.UserInputs) > 0:
+			outcome += fmt.Sprintf(" (contains %s, -%.1f bits)", strings.Join(result.UserInputs, ", "), result.Penalty)
+		case result.InputCount == 1:
+			outcome += fmt.Sprintf(" (contains a user input, -%.1f bits)", result.Penalty)
+		case result.InputCount > 1:
+			outcome += fmt.Sprintf(" (contains %d user inputs, -%.1f bits)", result.InputCount, result.Penalty)
+		}
+		row = append(row, outcome)
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// styled after alignment, escape codes would throw tabwriter off
+	lines := strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")
+	for i, line := range lines {
+		style := ui.ValueStyle
+		if i == 0 {
+			style = ui.LabelStyle
+		} else if !results[i-1].Pass {
+			style = ui.WarningStyle
+		}
+		if _, err := fmt.Fprintln(w, style.Render(line)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exitCheckError(err error) {
+	ui.InitializeStyles(ui.GetDefaultTheme())
+	fmt.Fprintln(os.Stderr, ui.WarningStyle.Render(fmt.Sprintf("Error: %v", err)))
+	os.Exit(checkErrorStatus)
+}
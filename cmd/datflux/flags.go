@@ -0,0 +1,419 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"datflux/internal/password"
+	"datflux/internal/ui"
+)
+
+// policy flags shared by `datflux now` and the TUI
+type policyFlags struct {
+	length    int
+	minLength int
+	maxLength int
+	noSymbols bool
+	noDigits  bool
+	noUpper   bool
+	noLower   bool
+	charset   string
+	chars     string
+	exclude   string
+	pwquality string
+
+	// constraints, enforced by rejection
+	startLetter    bool
+	maxRepeat      int
+	maxSequence    int
+	maxOccurrences int
+}
+
+func (pf *policyFlags) register(fs *flag.FlagSet) {
+	fs.IntVar(&pf.length, "length", 0, "exact password length")
+	fs.IntVar(&pf.length, "l", 0, "exact password length")
+	fs.IntVar(&pf.minLength, "min", 0, "minimum password length")
+	fs.IntVar(&pf.maxLength, "max", 0, "maximum password length")
+	fs.BoolVar(&pf.noSymbols, "no-symbols", false, "exclude symbols")
+	fs.BoolVar(&pf.noDigits, "no-digits", false, "exclude digits")
+	fs.BoolVar(&pf.noUpper, "no-upper", false, "exclude uppercase letters")
+	fs.BoolVar(&pf.noLower, "no-lower", false, "exclude lowercase letters")
+	fs.StringVar(&pf.charset, "charset", "", "charset preset name")
+	fs.StringVar(&pf.chars, "chars", "", "literal alphabet to draw from")
+	fs.StringVar(&pf.exclude, "exclude", "", "characters to never use")
+	fs.StringVar(&pf.pwquality, "pwquality", "", "pwquality.conf whose rules the password must pass")
+	fs.BoolVar(&pf.startLetter, "start-letter", false, "first character must be a letter")
+	fs.IntVar(&pf.maxRepeat, "max-repeat", 0, "longest run of one identical character")
+	fs.IntVar(&pf.maxSequence, "max-sequence", 0, "longest ascending, descending or keyboard run")
+	fs.IntVar(&pf.maxOccurrences, "max-occurrences", 0, "times any one character may appear, not combinable with --max-repeat or --max-sequence")
+}
+
+// applies the flags on top of the default policy
+func (pf *policyFlags) policy() (password.Policy, error) {
+	policy := password.DefaultPolicy()
+
+	if pf.length > 0 && (pf.minLength > 0 || pf.maxLength > 0) {
+		return policy, fmt.Errorf("--length cannot be combined with --min/--max")
+	}
+
+	switch {
+	case pf.length > 0:
+		policy.MinLength = pf.length
+		policy.MaxLength = pf.length
+	case pf.minLength > 0 && pf.maxLength > 0:
+		policy.MinLength = pf.minLength
+		policy.MaxLength = pf.maxLength
+	case pf.minLength > 0:
+		// keep the default spread above the new minimum
+		policy.MinLength = pf.minLength
+		policy.MaxLength = max(policy.MaxLength, pf.minLength)
+	case pf.maxLength > 0:
+		policy.MaxLength = pf.maxLength
+		policy.MinLength = min(policy.MinLength, pf.maxLength)
+	}
+
+	policy.Symbols = !pf.noSymbols
+	policy.Digits = !pf.noDigits
+	policy.Upper = !pf.noUpper
+	policy.Lower = !pf.noLower
+
+	// a mistyped preset name mustn't become a tiny literal alphabet
+	if pf.charset != "" && pf.chars != "" {
+		return policy, fmt.Errorf("--charset cannot be combined with --chars")
+	}
+	if pf.charset != "" {
+		if _, ok := password.LookupCharset(pf.charset); !ok {
+			return policy, fmt.Errorf("unknown charset %q (%s), use --chars for a literal alphabet",
+				pf.charset, charsetNames())
+		}
+		policy.Charset = pf.charset
+	}
+	policy.CustomChars = pf.chars
+	policy.Exclude = pf.exclude
+
+	policy.StartWithLetter = pf.startLetter
+	policy.MaxRepeat = pf.maxRepeat
+	policy.MaxSequence = pf.maxSequence
+	policy.MaxOccurrences = pf.maxOccurrences
+
+	// the system rules tighten whatever the other flags asked for
+	if pf.pwquality != "" {
+		quality, err := password.LoadPWQuality(pf.pwquality)
+		if err != nil {
+			return policy, err
+		}
+		return quality.Apply(policy)
+	}
+
+	return policy, policy.Validate()
+}
+
+// true when any policy flag was given, profiles bring their own policy
+func (pf *policyFlags) isSet() bool {
+	return *pf != policyFlags{}
+}
+
+// "alnum, no-ambiguous, ..." for error messages
+func charsetNames() string {
+	var names []string
+	for _, preset := range password.CharsetPresets() {
+		names = append(names, preset.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// generation mode flags shared by `datflux now` and the TUI
+type modeFlags struct {
+	mode string
+
+	// passphrase
+	words     int
+	separator string
+	digit     bool
+	wordlist  string
+
+	// pronounceable
+	syllables    int
+	suffixDigits int
+
+	// template
+	pattern string
+	profile string
+
+	regex string
+
+	capitalize bool
+}
+
+func (mf *modeFlags) register(fs *flag.FlagSet) {
+	passphraseDefaults := password.DefaultPassphraseOptions()
+	fs.StringVar(&mf.mode, "mode", "", "characters, passphrase, pronounceable, template or regex")
+	fs.IntVar(&mf.words, "words", 0, "generate a passphrase with this many words")
+	fs.IntVar(&mf.words, "w", 0, "generate a passphrase with this many words")
+	fs.StringVar(&mf.separator, "separator", passphraseDefaults.Separator, "passphrase word separator")
+	fs.BoolVar(&mf.digit, "digit", false, "insert a random digit into the passphrase")
+	fs.StringVar(&mf.wordlist, "wordlist", password.EFFLargeWordlistName, "passphrase wordlist name")
+	fs.IntVar(&mf.syllables, "syllables", 0, "generate a pronounceable password with this many syllables")
+	fs.IntVar(&mf.suffixDigits, "suffix-digits", 0, "digits appended to a pronounceable password")
+	fs.BoolVar(&mf.capitalize, "capitalize", false, "capitalise passphrase words or one syllable")
+	fs.StringVar(&mf.pattern, "pattern", "", "template such as Cvcc-9999-!!")
+	fs.StringVar(&mf.profile, "profile", "", "named template or policy profile from profiles.conf")
+	fs.StringVar(&mf.regex, "regex", "", "bounded regular expression the password must match")
+}
+
+// --words and --syllables select their mode, --mode picks explicitly
+func (mf *modeFlags) resolveMode() (password.Mode, error) {
+	var selected []password.Mode
+
+	switch mf.mode {
+	case "":
+	case "characters", "chars":
+		selected = append(selected, password.ModeCharacters)
+	case "passphrase", "words":
+		selected = append(selected, password.ModePassphrase)
+	case "pronounceable", "syllables":
+		selected = append(selected, password.ModePronounceable)
+	case "template", "pattern":
+		selected = append(selected, password.ModeTemplate)
+	case "regex":
+		selected = append(selected, password.ModeRegex)
+	default:
+		return password.ModeCharacters, fmt.Errorf("unknown mode %q", mf.mode)
+	}
+
+	if mf.words > 0 {
+		selected = append(selected, password.ModePassphrase)
+	}
+	if mf.syllables > 0 || mf.suffixDigits > 0 {
+		selected = append(selected, password.ModePronounceable)
+	}
+	if mf.pattern != "" {
+		selected = append(selected, password.ModeTemplate)
+	}
+	if mf.regex != "" {
+		selected = append(selected, password.ModeRegex)
+	}
+
+	if len(selected) == 0 {
+		return password.ModeCharacters, nil
+	}
+	for _, mode := range selected[1:] {
+		if mode != selected[0] {
+			return password.ModeCharacters, fmt.Errorf("conflicting modes: %s and %s", selected[0], mode)
+		}
+	}
+	return selected[0], nil
+}
+
+// true when a flag picks the mode, profiles pick their own
+func (mf *modeFlags) selectsMode() bool {
+	return mf.mode != "" || mf.words > 0 || mf.syllables > 0 || mf.suffixDigits > 0 ||
+		mf.pattern != "" || mf.regex != ""
+}
+
+func (mf *modeFlags) template() (*password.Template, error) {
+	if mf.pattern == "" {
+		return nil, nil
+	}
+	return password.ParseTemplate(mf.pattern)
+}
+
+func (mf *modeFlags) passphraseOptions() password.PassphraseOptions {
+	opts := password.DefaultPassphraseOptions()
+	if mf.words > 0 {
+		opts.Words = mf.words
+	}
+	opts.Separator = mf.separator
+	opts.Capitalize = mf.capitalize
+	opts.InsertDigit = mf.digit
+	return opts
+}
+
+func (mf *modeFlags) pronounceableOptions() password.PronounceableOptions {
+	opts := password.DefaultPronounceableOptions()
+	if mf.syllables > 0 {
+		opts.Syllables = mf.syllables
+	}
+	opts.Capitalize = mf.capitalize
+	opts.Digits = mf.suffixDigits
+	return opts
+}
+
+// paranoia mode settings shared by `datflux now -p` and the TUI's [p]
+type paranoiaFlags struct {
+	candidates    int
+	length        string
+	shufflePasses int
+}
+
+func (pf *paranoiaFlags) register(fs *flag.FlagSet) {
+	fs.IntVar(&pf.candidates, "candidates", 0, "paranoia candidates per password, 1 disables selection")
+	fs.StringVar(&pf.length, "paranoia-length", "", "paranoia length, e.g. 64 or 48-80")
+	fs.IntVar(&pf.shufflePasses, "shuffle-passes", -1, "paranoia shuffle passes")
+}
+
+// defaults everywhere but the candidate count, which differs between CLI and TUI
+func (pf *paranoiaFlags) options(defaultCandidates int) (password.ParanoiaOptions, error) {
+	opts := password.DefaultParanoiaOptions()
+	opts.Candidates = defaultCandidates
+	if pf.candidates != 0 {
+		opts.Candidates = pf.candidates
+	}
+	if pf.shufflePasses >= 0 {
+		opts.ShufflePasses = pf.shufflePasses
+	}
+
+	if pf.length != "" {
+		low, high, isRange := strings.Cut(pf.length, "-")
+		if !isRange {
+			high = low
+		}
+		minLength, errMin := strconv.Atoi(low)
+		maxLength, errMax := strconv.Atoi(high)
+		if errMin != nil || errMax != nil {
+			return opts, fmt.Errorf("--paranoia-length: %q is not N or MIN-MAX", pf.length)
+		}
+		opts.MinLength, opts.MaxLength = minLength, maxLength
+	}
+
+	return opts, opts.Validate()
+}
+
+// words zxcvbn should try first, shared by the TUI and check
+type userInputFlags struct {
+	words      string
+	dictionary string
+}
+
+func (uf *userInputFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&uf.words, "user-inputs", "", "comma-separated names, sites or words to penalise")
+	fs.StringVar(&uf.dictionary, "user-dict", "", "file of words to penalise, one per line")
+}
+
+func (uf *userInputFlags) inputs() ([]string, error) {
+	var inputs []string
+	for _, word := range strings.Split(uf.words, ",") {
+		if word = strings.TrimSpace(word); word != "" {
+			inputs = append(inputs, word)
+		}
+	}
+
+	if uf.dictionary != "" {
+		words, err := password.LoadUserInputs(uf.dictionary)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, words...)
+	}
+	return inputs, nil
+}
+
+// dictionary and banned-word lists, shared by now, the TUI and check
+type blacklistFlags struct {
+	paths string
+}
+
+func (bf *blacklistFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&bf.paths, "blacklist", "", "comma-separated wordlists to reject, e.g. /usr/share/dict/words")
+}
+
+// nil when no list was given
+func (bf *blacklistFlags) load() (*password.Blacklist, error) {
+	if bf.paths == "" {
+		return nil, nil
+	}
+	return password.LoadBlacklist(strings.Split(bf.paths, ",")...)
+}
+
+// implemented by both password.Generator and ui.Dashboard
+type generatorSettings interface {
+	SetPolicy(password.Policy) error
+	SetPassphraseOptions(password.PassphraseOptions) error
+	SetPronounceableOptions(password.PronounceableOptions) error
+	SetWordlist(*password.Wordlist)
+	SetTemplate(*password.Template)
+	SetRegex(*password.Regex)
+	SetMode(password.Mode)
+	ApplyProfile(password.Profile) error
+}
+
+func configureGenerator(target generatorSettings, pf *policyFlags, mf *modeFlags) error {
+	if mf.profile != "" && (pf.isSet() || mf.selectsMode()) {
+		return fmt.Errorf("--profile cannot be combined with mode or policy flags")
+	}
+
+	policy, err := pf.policy()
+	if err != nil {
+		return err
+	}
+	if err := target.SetPolicy(policy); err != nil {
+		return err
+	}
+
+	mode, err := mf.resolveMode()
+	if err != nil {
+		return err
+	}
+
+	if err := target.SetPassphraseOptions(mf.passphraseOptions()); err != nil {
+		return err
+	}
+	if err := target.SetPronounceableOptions(mf.pronounceableOptions()); err != nil {
+		return err
+	}
+
+	wordlist, err := password.FindWordlist(mf.wordlist)
+	if err != nil {
+		return err
+	}
+	target.SetWordlist(wordlist)
+
+	template, err := mf.template()
+	if err != nil {
+		return err
+	}
+	if mode == password.ModeTemplate && template == nil {
+		return fmt.Errorf("template mode needs --pattern")
+	}
+	target.SetTemplate(template)
+
+	if mode == password.ModeRegex {
+		if mf.regex == "" {
+			return fmt.Errorf("regex mode needs --regex")
+		}
+		regex, err := password.ParseRegex(mf.regex)
+		if err != nil {
+			return err
+		}
+		target.SetRegex(regex)
+	}
+
+	target.SetMode(mode)
+
+	// the profile replaces the default policy or template and its mode
+	if mf.profile != "" {
+		profile, err := password.FindProfile(password.ProfilesPath(), mf.profile)
+		if err != nil {
+			return err
+		}
+		return target.ApplyProfile(profile)
+	}
+	return nil
+}
+
+// flag set that reports errors through the themed warning style
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+func exitWithError(err error) {
+	ui.InitializeStyles(ui.GetDefaultTheme())
+	fmt.Fprintln(os.Stderr, ui.WarningStyle.Render(fmt.Sprintf("Error: %v", err)))
+	os.Exit(1)
+}
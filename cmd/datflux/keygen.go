@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"slices"
+	"strings"
+
+	"datflux/internal/keygen"
+	"datflux/internal/ui"
+
+	"golang.org/x/term"
+)
+
+// `datflux keygen ssh|wireguard|age`, the private key goes to a new 0600
+// file and the public half to stdout (and PATH.pub)
+func keygenCommand(args []string) {
+	if len(args) == 0 || !slices.Contains(keygen.Kinds, keygen.Kind(args[0])) {
+		exitWithError(fmt.Errorf("usage: datflux keygen ssh|wireguard|age [--out PATH] [--comment TEXT] [--passphrase]"))
+	}
+	kind := keygen.Kind(args[0])
+
+	var out, comment string
+	var withPassphrase, force bool
+	fs := newFlagSet("keygen " + string(kind))
+	fs.StringVar(&out, "out", defaultKeyPath(kind), "private key file, the public key goes to PATH.pub")
+	fs.StringVar(&comment, "comment", defaultKeyComment(), "SSH key comment")
+	fs.BoolVar(&withPassphrase, "passphrase", false, "encrypt the SSH key, prompts or reads the first line of stdin")
+	fs.BoolVar(&force, "force", false, "overwrite existing key files")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printHelp()
+			return
+		}
+		exitWithError(err)
+	}
+	if fs.NArg() > 0 {
+		exitWithError(fmt.Errorf("keygen takes no arguments, got %q", fs.Arg(0)))
+	}
+	if withPassphrase && kind != keygen.KindSSH {
+		exitWithError(fmt.Errorf("--passphrase only applies to SSH keys"))
+	}
+
+	ui.InitializeStyles(ui.GetDefaultTheme())
+
+	var passphrase []byte
+	if withPassphrase {
+		var err error
+		if passphrase, err = readPassphrase(); err != nil {
+			exitWithError(err)
+		}
+	}
+
+	collector := warmCollector()
+	defer collector.Close()
+
+	var pair keygen.KeyPair
+	var err error
+	switch kind {
+	case keygen.KindSSH:
+		pair, err = keygen.SSH(collector, comment, passphrase)
+	case keygen.KindWireGuard:
+		pair, err = keygen.WireGuard(collector)
+	case keygen.KindAge:
+		pair, err = keygen.Age(collector)
+	}
+	if err == nil {
+		err = writeKeyPair(out, pair, force)
+	}
+	if err != nil {
+		collector.Close()
+		exitWithError(err)
+	}
+
+	info := fmt.Sprintf("Wrote %s (0600) and %s.pub", out, out)
+	if pair.Fingerprint != "" {
+		info += ", " + pair.Fingerprint
+	}
+	fmt.Fprintln(os.Stderr, ui.ValueStyle.Render(info))
+	fmt.Println(pair.Public)
+}
+
+func defaultKeyPath(kind keygen.Kind) string {
+	switch kind {
+	case keygen.KindWireGuard:
+		return "wg.key"
+	case keygen.KindAge:
+		return "age.key"
+	}
+	return "id_ed25519"
+}
+
+// user@host like ssh-keygen
+func defaultKeyComment() string {
+	name := "datflux"
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		name += "@" + host
+	}
+	return name
+}
+
+// asked twice on a terminal, otherwise the first line of stdin
+func readPassphrase() ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			return nil, fmt.Errorf("no passphrase on stdin: %w", err)
+		}
+		return []byte(line), nil
+	}
+
+	fmt.Fprint(os.Stderr, ui.ValueStyle.Render("Passphrase: "))
+	first, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprint(os.Stderr, ui.ValueStyle.Render("Again: "))
+	second, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(first) == 0:
+		return nil, fmt.Errorf("empty passphrase, drop --passphrase for an unencrypted key")
+	case !bytes.Equal(first, second):
+		return nil, fmt.Errorf("passphrases don't match")
+	}
+	return first, nil
+}
+
+// the private key is created 0600 and never replaced unless forced, so a
+// typo can't destroy an existing key
+func writeKeyPair(path string, pair keygen.KeyPair, force bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+
+		// checked up front so a leftover .pub doesn't strand a new key
+		for _, existing := range []string{path, path + ".pub"} {
+			if _, err := os.Lstat(existing); err == nil {
+				return fmt.Errorf("%s already exists, use --force to overwrite it", existing)
+			}
+		}
+	}
+
+	if err := writeKeyFile(path, pair.Private, flags, 0600); err != nil {
+		return err
+	}
+	return writeKeyFile(path+".pub", []byte(pair.Public+"\n"), flags, 0644)
+}
+
+func writeKeyFile(path string, content []byte, flags int, perm os.FileMode) error {
+	file, err := os.OpenFile(path, flags, perm)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	// an existing file keeps its mode on --force, tighten it
+	if err := file.Chmod(perm); err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
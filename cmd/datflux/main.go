@@ -1,8 +1,12 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"math/big"
 	"os"
+	"strings"
 	"time"
 
 	"datflux/internal/entropy"
@@ -19,17 +23,100 @@ func main() {
 		return
 	}
 
-	launchTUI()
+	launchTUI(nil)
 }
 
-func launchTUI() {
+// analyses kept by the TUI and check, far more than a screen shows at once
+const analyzerCacheSize = 64
+
+// generator flags given without a subcommand apply to the TUI
+func launchTUI(args []string) {
+	var pf policyFlags
+	var mf modeFlags
+	var parf paranoiaFlags
+	var uf userInputFlags
+	var bf breachFlags
+	var blf blacklistFlags
+	var amf attackModelFlags
+	var wifiSSID string
+	fs := newFlagSet("datflux")
+	pf.register(fs)
+	mf.register(fs)
+	parf.register(fs)
+	uf.register(fs)
+	bf.register(fs)
+	blf.register(fs)
+	amf.register(fs)
+	fs.StringVar(&wifiSSID, "wifi-ssid", "", "show [v] QR codes as this WPA network")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printHelp()
+			return
+		}
+		exitWithError(err)
+	}
+
+	userInputs, err := uf.inputs()
+	if err != nil {
+		exitWithError(err)
+	}
+	breachIndex, err := bf.open()
+	if err != nil {
+		exitWithError(err)
+	}
+	blacklist, err := blf.load()
+	if err != nil {
+		exitWithError(err)
+	}
+	attackModels, err := amf.load()
+	if err != nil {
+		exitWithError(err)
+	}
+
 	collector := entropy.NewCollector(time.Millisecond*100, 50)
+
+	// the applied profile's words join the flag's as profiles are cycled
+	generator := password.NewGenerator(collector)
+	analyzers := []password.Analyzer{
+		password.ZxcvbnAnalyzer{UserInputs: userInputs, Context: generator.GetUserInputs},
+		password.NewExactAnalyzer(generator),
+	}
+	if breachIndex != nil {
+		defer breachIndex.Close()
+		analyzers = append(analyzers, password.BreachAnalyzer{Index: breachIndex})
+	}
+	if blacklist != nil {
+		generator.SetBlacklist(blacklist)
+		analyzers = append(analyzers, password.BlacklistAnalyzer{Blacklist: blacklist})
+	}
+	analyzer := password.NewCachedAnalyzer(password.Combine(analyzers...), analyzerCacheSize, generator.SettingsVersion)
+	dashboard := ui.NewDashboardModel(collector, generator, analyzer)
+	dashboard.SetAttackModels(attackModels)
+	if wifiSSID != "" {
+		if err := dashboard.SetWiFiSSID(wifiSSID); err != nil {
+			collector.Close()
+			exitWithError(err)
+		}
+	}
+	if err := configureGenerator(dashboard, &pf, &mf); err != nil {
+		collector.Close()
+		exitWithError(err)
+	}
+	if opts, err := parf.options(password.DefaultParanoiaOptions().Candidates); err != nil {
+		collector.Close()
+		exitWithError(err)
+	} else if err := dashboard.SetParanoiaOptions(opts); err != nil {
+		collector.Close()
+		exitWithError(err)
+	}
+
 	noiseGen := entropy.NewNoiseGenerator(collector)
 	defer collector.Close()
 	defer noiseGen.Stop()
 
 	p := tea.NewProgram(
-		ui.NewDashboardModel(collector),
+		dashboard,
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
 	)
@@ -42,17 +129,36 @@ func launchTUI() {
 
 func handleSubcommands(args []string) {
 	if len(args) == 0 {
-		launchTUI()
+		launchTUI(nil)
 		return
 	}
 
 	switch args[0] {
 	case "now":
 		generatePasswordNow(args[1:])
+	case "wordlists":
+		listWordlists()
+	case "check":
+		checkPasswords(args[1:])
+	case "breach":
+		breachCommand(args[1:])
+	case "models":
+		modelsCommand(args[1:])
+	case "token":
+		generateTokens(args[1:])
+	case "keygen":
+		keygenCommand(args[1:])
+	case "totp":
+		totpCommand(args[1:])
 	case "help", "--help", "-h":
 		ui.Wiper()
 		printHelp()
 	default:
+		if strings.HasPrefix(args[0], "-") {
+			launchTUI(args)
+			return
+		}
+
 		ui.InitializeStyles(ui.GetDefaultTheme())
 		ui.Wiper()
 		errorMessage := fmt.Sprintf("Unknown subcommand: %s\n", args[0])
@@ -64,21 +170,125 @@ func handleSubcommands(args []string) {
 
 func generatePasswordNow(args []string) {
 	// flag parsing
-	paranoiaMode := false
-	for _, arg := range args {
-		if arg == "--paranoia" || arg == "-p" {
-			paranoiaMode = true
-		} else if arg == "--help" || arg == "-h" {
+	var paranoiaMode bool
+	var saveProfile string
+	var pf policyFlags
+	var mf modeFlags
+	var parf paranoiaFlags
+	var blf blacklistFlags
+	fs := newFlagSet("now")
+	fs.BoolVar(&paranoiaMode, "paranoia", false, "enable paranoia mode")
+	fs.BoolVar(&paranoiaMode, "p", false, "enable paranoia mode")
+	fs.StringVar(&saveProfile, "save-profile", "", "save --pattern as a named profile")
+	pf.register(fs)
+	mf.register(fs)
+	parf.register(fs)
+	blf.register(fs)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
 			printHelp()
 			return
 		}
+		exitWithError(err)
+	}
+
+	// fewer candidates for the CLI
+	paranoiaOpts, err := parf.options(5)
+	if err != nil {
+		exitWithError(err)
+	}
+	blacklist, err := blf.load()
+	if err != nil {
+		exitWithError(err)
+	}
+
+	if mode, err := mf.resolveMode(); err != nil {
+		exitWithError(err)
+	} else if paranoiaMode && mode != password.ModeCharacters {
+		exitWithError(fmt.Errorf("--paranoia only applies to character passwords, not %s", mode))
+	}
+	if saveProfile != "" && mf.profile != "" {
+		exitWithError(fmt.Errorf("--save-profile cannot be combined with --profile"))
 	}
 
 	ui.InitializeStyles(ui.GetDefaultTheme())
 
+	if saveProfile != "" {
+		if mf.pattern == "" {
+			exitWithError(fmt.Errorf("--save-profile needs --pattern"))
+		}
+
+		profile := password.Profile{Name: saveProfile, Pattern: mf.pattern}
+		if err := password.SaveProfile(password.ProfilesPath(), profile); err != nil {
+			exitWithError(err)
+		}
+		fmt.Fprintln(os.Stderr, ui.ValueStyle.Render(
+			fmt.Sprintf("Saved profile %q to %s", saveProfile, password.ProfilesPath())))
+	}
+
+	collector := warmCollector()
+	defer collector.Close()
+
+	passGen := password.NewGenerator(collector)
+	if err := configureGenerator(passGen, &pf, &mf); err != nil {
+		collector.Close()
+		exitWithError(err)
+	}
+	if paranoiaMode && passGen.GetMode() != password.ModeCharacters {
+		collector.Close()
+		exitWithError(fmt.Errorf("--paranoia only applies to character passwords, not %s", passGen.GetMode()))
+	}
+	if err := passGen.SetParanoiaOptions(paranoiaOpts); err != nil {
+		collector.Close()
+		exitWithError(err)
+	}
+	if err := passGen.SetParanoiaMode(paranoiaMode, paranoiaOpts.Candidates); err != nil {
+		collector.Close()
+		exitWithError(err)
+	}
+	passGen.SetBlacklist(blacklist)
+
+	// nosec G404 -- uses cryptographically secure entropy from Fortuna
+	pw, err := passGen.Generate()
+	if err != nil {
+		collector.Close()
+		exitWithError(err)
+	}
+
+	// size of the match space on stderr, stdout stays script-friendly
+	if regex := passGen.GetRegex(); regex != nil && passGen.GetMode() == password.ModeRegex {
+		fmt.Fprintln(os.Stderr, ui.ValueStyle.Render(
+			fmt.Sprintf("Match space: %s strings (%.1f bits)",
+				new(big.Float).SetInt(regex.Count()).Text('g', 4), regex.Entropy())))
+	}
+
+	// what the candidate selection costs, on stderr as well
+	if paranoiaMode && paranoiaOpts.Candidates > 1 {
+		shannon, minEntropy := paranoiaOpts.SelectionCost()
+		fmt.Fprintln(os.Stderr, ui.ValueStyle.Render(
+			fmt.Sprintf("Paranoia: %s, selection costs up to %.1f bits (%.1f bits min-entropy)",
+				paranoiaOpts, shannon, minEntropy)))
+	}
+
+	// how often the blacklist forced a redraw
+	if blacklist != nil {
+		stats := passGen.GetBlacklistStats()
+		fmt.Fprintln(os.Stderr, ui.ValueStyle.Render(
+			fmt.Sprintf("Blacklist: %d of %d draws rejected", stats.Rejected, stats.Drawn)))
+	}
+
+	fmt.Println()
+
+	// nosec G107 -- intentional display as CLI output
+	fmt.Println(pw)
+}
+
+// entropy collector for one-shot commands, the noise generator runs just
+// long enough to stir the pools
+func warmCollector() *entropy.Collector {
 	// entropy collector with shorter initialization time
 	collector := entropy.NewCollector(time.Millisecond*50, 20)
-	defer collector.Close()
 
 	// run the noise generator briefly
 	noiseGen := entropy.NewNoiseGenerator(collector)
@@ -87,16 +297,26 @@ func generatePasswordNow(args []string) {
 	time.Sleep(200 * time.Millisecond)
 	noiseGen.Stop()
 
-	passGen := password.NewGenerator(collector)
-	passGen.SetParanoiaMode(paranoiaMode, 5) // fewer samples for CLI
+	return collector
+}
 
-	// nosec G404 -- uses cryptographically secure entropy from Fortuna
-	pw := passGen.Generate()
+// every wordlist `--wordlist` accepts, with its per-word entropy
+func listWordlists() {
+	ui.InitializeStyles(ui.GetDefaultTheme())
 
+	fmt.Println(ui.ValueStyle.Render(fmt.Sprintf("Wordlist directory: %s", password.WordlistDir())))
 	fmt.Println()
 
-	// nosec G107 -- intentional display as CLI output
-	fmt.Println(pw)
+	for _, name := range password.AvailableWordlists() {
+		wordlist, err := password.FindWordlist(name)
+		if err != nil {
+			fmt.Println(ui.WarningStyle.Render(fmt.Sprintf("  %-16s %v", name, err)))
+			continue
+		}
+
+		line := fmt.Sprintf("  %-16s %6d words  %5.2f bits/word", name, len(wordlist.Words), wordlist.EntropyPerWord())
+		fmt.Println(ui.ValueStyle.Render(line))
+	}
 }
 
 func printHelp() {
@@ -105,4 +325,5 @@ func printHelp() {
 	header := ui.Logo() + "\n"
 
 	fmt.Println(header)
+	fmt.Println(ui.Usage())
 }
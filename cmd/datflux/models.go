@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"datflux/internal/password"
+	"datflux/internal/ui"
+)
+
+// `datflux models` lists the attack models, `datflux models import FILE`
+// fills in per-GPU rates from `hashcat --benchmark` output
+func modelsCommand(args []string) {
+	var mf attackModelFlags
+	importing := len(args) > 0 && args[0] == "import"
+	if importing {
+		args = args[1:]
+	}
+
+	fs := newFlagSet("models")
+	mf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printHelp()
+			return
+		}
+		exitWithError(err)
+	}
+
+	ui.InitializeStyles(ui.GetDefaultTheme())
+
+	if importing {
+		if fs.NArg() != 1 {
+			exitWithError(fmt.Errorf("usage: datflux models import [--attack-models PATH] BENCHMARK"))
+		}
+		importBenchmark(mf.configPath(), fs.Arg(0))
+		return
+	}
+	if fs.NArg() > 0 {
+		exitWithError(fmt.Errorf("unknown models command %q", fs.Arg(0)))
+	}
+
+	models, err := mf.load()
+	if err != nil {
+		exitWithError(err)
+	}
+
+	fmt.Println(ui.ValueStyle.Render(fmt.Sprintf("Attack models: %s", mf.configPath())))
+	fmt.Println()
+	for _, model := range models {
+		line := fmt.Sprintf("  %-24s %12s  %s", model.Name, password.FormatRate(model.GuessesPerSec), model.Description)
+		if model.Quantum != nil {
+			line = fmt.Sprintf("  %-24s %12s  %s, %s, %.0f bits hold %g years", model.Name, "Grover",
+				model.Description, model.Quantum, model.Quantum.RequiredBits(), model.Quantum.TargetYears)
+		}
+		fmt.Println(ui.ValueStyle.Render(line))
+	}
+}
+
+func importBenchmark(configPath, benchmarkPath string) {
+	benchmark, err := os.Open(benchmarkPath)
+	if err != nil {
+		exitWithError(err)
+	}
+	defer benchmark.Close()
+
+	rates, err := password.ImportHashcatBenchmark(configPath, benchmark)
+	if err != nil {
+		exitWithError(fmt.Errorf("%s: %w", benchmarkPath, err))
+	}
+
+	var imported []string
+	for _, name := range password.HashAlgorithms() {
+		if rate, ok := rates[name]; ok {
+			imported = append(imported, fmt.Sprintf("%s %s", name, password.FormatRate(rate)))
+		}
+	}
+	fmt.Fprintln(os.Stderr, ui.ValueStyle.Render(
+		fmt.Sprintf("Imported per-GPU rates into %s: %s", configPath, strings.Join(imported, ", "))))
+}
+
+// attack models shared by the TUI, check and `datflux models`
+type attackModelFlags struct {
+	path         string
+	quantumYears float64
+}
+
+func (mf *attackModelFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&mf.path, "attack-models", "", "attack model config (default "+password.AttackModelsPath()+")")
+	fs.Float64Var(&mf.quantumYears, "quantum-years", 0, "years passwords should resist the quantum models (default 100)")
+}
+
+func (mf *attackModelFlags) configPath() string {
+	if mf.path != "" {
+		return mf.path
+	}
+	return password.AttackModelsPath()
+}
+
+// the built-ins plus the configured models, a file given by flag must exist
+func (mf *attackModelFlags) load() ([]password.AttackModel, error) {
+	if mf.path != "" {
+		if _, err := os.Stat(mf.path); err != nil {
+			return nil, err
+		}
+	}
+	if mf.quantumYears < 0 {
+		return nil, fmt.Errorf("--quantum-years must be positive")
+	}
+
+	models, err := password.LoadAttackModels(mf.configPath())
+	if err != nil || mf.quantumYears == 0 {
+		return models, err
+	}
+	for i, model := range models {
+		if model.Quantum != nil {
+			params := *model.Quantum
+			params.TargetYears = mf.quantumYears
+			models[i].Quantum = &params
+		}
+	}
+	return models, nil
+}
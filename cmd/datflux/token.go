@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"datflux/internal/password"
+	"datflux/internal/ui"
+)
+
+// `datflux token`, secrets for machines: API keys, HMAC and cookie keys,
+// framework secret keys; tokens go to stdout one per line
+func generateTokens(args []string) {
+	var opts password.TokenOptions
+	var format string
+	var count int
+	fs := newFlagSet("token")
+	fs.IntVar(&opts.Bytes, "bytes", 0, fmt.Sprintf("random bytes (default %d, %d-%d)",
+		password.DefaultTokenBytes, password.MinTokenBytes, password.MaxTokenBytes))
+	fs.StringVar(&format, "format", string(password.TokenHex), "hex, base64, base64url, base32, uuid or ulid")
+	fs.StringVar(&opts.Prefix, "prefix", "", "text before the token, e.g. dfx_live_")
+	fs.BoolVar(&opts.Checksum, "checksum", false, "append a CRC32 of the token for secret scanners")
+	fs.IntVar(&count, "count", 1, "number of tokens")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printHelp()
+			return
+		}
+		exitWithError(err)
+	}
+	if fs.NArg() > 0 {
+		exitWithError(fmt.Errorf("token takes no arguments, got %q", fs.Arg(0)))
+	}
+
+	opts.Format = password.TokenFormat(format)
+	if err := opts.Validate(); err != nil {
+		exitWithError(err)
+	}
+	if count < 1 || count > 10000 {
+		exitWithError(fmt.Errorf("--count must be between 1 and 10000 (got %d)", count))
+	}
+
+	ui.InitializeStyles(ui.GetDefaultTheme())
+
+	collector := warmCollector()
+	defer collector.Close()
+
+	tokens := make([]string, 0, count)
+	for range count {
+		token, err := password.GenerateToken(collector, opts)
+		if err != nil {
+			collector.Close()
+			exitWithError(err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	// what the tokens are on stderr, stdout stays script-friendly
+	fmt.Fprintln(os.Stderr, ui.ValueStyle.Render(
+		fmt.Sprintf("Token: %s, %.0f random bits", opts, opts.Entropy())))
+	for _, token := range tokens {
+		fmt.Println(token)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"datflux/internal/qr"
+	"datflux/internal/totp"
+	"datflux/internal/ui"
+)
+
+// `datflux totp`, a new authenticator secret shown as a QR code to scan,
+// the otpauth URI goes to stdout for password managers and scripts
+func totpCommand(args []string) {
+	opts := totp.DefaultOptions()
+	var algorithm string
+	fs := newFlagSet("totp")
+	fs.StringVar(&opts.Issuer, "issuer", "", "service name shown in the authenticator app")
+	fs.StringVar(&opts.Account, "account", defaultKeyComment(), "account name, e.g. an email address")
+	fs.IntVar(&opts.Digits, "digits", opts.Digits, "code length, 6-8")
+	fs.IntVar(&opts.Period, "period", opts.Period, "seconds each code is valid")
+	fs.StringVar(&algorithm, "algorithm", string(opts.Algorithm), "SHA1, SHA256 or SHA512")
+	fs.IntVar(&opts.Bytes, "bytes", opts.Bytes, fmt.Sprintf("secret size, %d-%d", totp.MinSecretBytes, totp.MaxSecretBytes))
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printHelp()
+			return
+		}
+		exitWithError(err)
+	}
+	if fs.NArg() > 0 {
+		exitWithError(fmt.Errorf("totp takes no arguments, got %q", fs.Arg(0)))
+	}
+
+	opts.Algorithm = totp.Algorithm(strings.ToUpper(algorithm))
+	if err := opts.Validate(); err != nil {
+		exitWithError(err)
+	}
+
+	ui.InitializeStyles(ui.GetDefaultTheme())
+
+	collector := warmCollector()
+	key, err := totp.Generate(collector, opts)
+	collector.Close()
+	if err != nil {
+		exitWithError(err)
+	}
+
+	uri := key.URI()
+	code, err := qr.Encode([]byte(uri), qr.Medium)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	label := opts.Account
+	if opts.Issuer != "" {
+		label = opts.Issuer + ":" + label
+	}
+	now := time.Now()
+	fmt.Fprintln(os.Stderr, ui.ValueStyle.Render(fmt.Sprintf("TOTP for %s: %s, %d digits every %ds, %d-bit secret",
+		label, opts.Algorithm, opts.Digits, opts.Period, 8*opts.Bytes)))
+	fmt.Fprintln(os.Stderr, ui.QRCode(code, os.Stderr))
+	fmt.Fprintln(os.Stderr, ui.ValueStyle.Render("Secret: "+groupSecret(key.Base32())))
+	fmt.Fprintln(os.Stderr, ui.ValueStyle.Render(fmt.Sprintf("Current code: %s (%ds left), next: %s",
+		key.Code(now), key.Remaining(now), key.Code(now.Add(time.Duration(opts.Period)*time.Second)))))
+	fmt.Println(uri)
+}
+
+// blocks of four for typing it in by hand, apps ignore the spaces
+func groupSecret(secret string) string {
+	var groups []string
+	for len(secret) > 4 {
+		groups = append(groups, secret[:4])
+		secret = secret[4:]
+	}
+	return strings.Join(append(groups, secret), " ")
+}
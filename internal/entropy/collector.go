@@ -40,7 +40,9 @@ type Collector struct {
 	entropyScore   float64
 }
 
-func getSeedFilePath() string {
+// datflux config directory, created with restrictive permissions
+// falls back to the current directory if there is no home
+func ConfigDir() string {
 	var basePath string
 
 	// try XDG_CONFIG_HOME first
@@ -51,7 +53,7 @@ func getSeedFilePath() string {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
 			// use the current directory as ultimate fallback
-			return "datflux_seed"
+			return "."
 		}
 		basePath = filepath.Join(homeDir, ".config", "datflux")
 	}
@@ -59,7 +61,16 @@ func getSeedFilePath() string {
 	// if it doesn't exist, create directory with restrictive permissions
 	os.MkdirAll(basePath, 0700)
 
-	return filepath.Join(basePath, "seed")
+	return basePath
+}
+
+func getSeedFilePath() string {
+	configDir := ConfigDir()
+	if configDir == "." {
+		return "datflux_seed"
+	}
+
+	return filepath.Join(configDir, "seed")
 }
 
 func NewCollector(samplingRate time.Duration, maxSamples int) *Collector {
@@ -0,0 +1,76 @@
+package keygen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BIP 173 bech32 as age uses it, without the 90 character limit
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	checksum := uint32(1)
+	for _, value := range values {
+		top := checksum >> 25
+		checksum = (checksum&0x1ffffff)<<5 ^ uint32(value)
+		for i := range 5 {
+			if (top>>i)&1 == 1 {
+				checksum ^= generator[i]
+			}
+		}
+	}
+	return checksum
+}
+
+func bech32ExpandHRP(hrp string) []byte {
+	expanded := make([]byte, 0, 2*len(hrp)+1)
+	for i := range len(hrp) {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := range len(hrp) {
+		expanded = append(expanded, hrp[i]&31)
+	}
+	return expanded
+}
+
+// regroups 8-bit bytes into 5-bit values, padding the last one
+func convertTo5Bits(data []byte) []byte {
+	var values []byte
+	accumulator, bits := uint32(0), 0
+	for _, b := range data {
+		accumulator = accumulator<<8 | uint32(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			values = append(values, byte(accumulator>>bits)&31)
+		}
+	}
+	if bits > 0 {
+		values = append(values, byte(accumulator<<(5-bits))&31)
+	}
+	return values
+}
+
+func bech32Encode(hrp string, data []byte) (string, error) {
+	if hrp == "" || strings.ToLower(hrp) != hrp {
+		return "", fmt.Errorf("bech32 prefix %q must be non-empty lowercase", hrp)
+	}
+
+	values := convertTo5Bits(data)
+	checksumInput := append(bech32ExpandHRP(hrp), values...)
+	checksumInput = append(checksumInput, 0, 0, 0, 0, 0, 0)
+	polymod := bech32Polymod(checksumInput) ^ 1
+
+	var builder strings.Builder
+	builder.WriteString(hrp)
+	builder.WriteByte('1')
+	for _, value := range values {
+		builder.WriteByte(bech32Charset[value])
+	}
+	for i := range 6 {
+		builder.WriteByte(bech32Charset[(polymod>>(5*(5-i)))&31])
+	}
+	return builder.String(), nil
+}
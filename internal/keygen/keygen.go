@@ -0,0 +1,135 @@
+package keygen
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"datflux/internal/entropy"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// key pairs seeded from the collector's Fortuna output, so keys come from
+// the same pool as the passwords
+type Kind string
+
+const (
+	KindSSH       Kind = "ssh"       // Ed25519, OpenSSH format
+	KindWireGuard Kind = "wireguard" // Curve25519
+	KindAge       Kind = "age"       // X25519 identity
+)
+
+var Kinds = []Kind{KindSSH, KindWireGuard, KindAge}
+
+// a generated key, Private is the key file as written to disk
+type KeyPair struct {
+	Kind        Kind
+	Private     []byte
+	Public      string // authorized_keys line, WireGuard public key or age recipient
+	Fingerprint string // SSH only
+}
+
+// the collector as a reader, 512 bits per refill
+type entropyReader struct {
+	collector *entropy.Collector
+	buf       []byte
+}
+
+func (r *entropyReader) Read(p []byte) (int, error) {
+	for n := 0; n < len(p); {
+		if len(r.buf) == 0 {
+			r.buf = r.collector.GetRawEntropy512()
+		}
+		copied := copy(p[n:], r.buf)
+		r.buf = r.buf[copied:]
+		n += copied
+	}
+	return len(p), nil
+}
+
+func seed(collector *entropy.Collector, size int) []byte {
+	key := make([]byte, size)
+	reader := entropyReader{collector: collector}
+	reader.Read(key) // never short, never fails
+	return key
+}
+
+// Ed25519 in OpenSSH's format, encrypted with bcrypt-pbkdf and AES-256-CTR
+// when a passphrase is given
+// only the seed comes from the collector, x/crypto draws the salt and the
+// check bytes from crypto/rand, neither is secret
+func SSH(collector *entropy.Collector, comment string, passphrase []byte) (KeyPair, error) {
+	private := ed25519.NewKeyFromSeed(seed(collector, ed25519.SeedSize))
+
+	var block *pem.Block
+	var err error
+	if len(passphrase) > 0 {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(private, comment, passphrase)
+	} else {
+		block, err = ssh.MarshalPrivateKey(private, comment)
+	}
+	if err != nil {
+		return KeyPair{}, err
+	}
+
+	public, err := ssh.NewPublicKey(private.Public())
+	if err != nil {
+		return KeyPair{}, err
+	}
+	authorized := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(public)), "\n")
+	if comment != "" {
+		authorized += " " + comment
+	}
+
+	return KeyPair{
+		Kind:        KindSSH,
+		Private:     pem.EncodeToMemory(block),
+		Public:      authorized,
+		Fingerprint: ssh.FingerprintSHA256(public),
+	}, nil
+}
+
+// base64 keys as `wg genkey | wg pubkey` prints them, the private key is
+// clamped the same way
+func WireGuard(collector *entropy.Collector) (KeyPair, error) {
+	scalar := seed(collector, 32)
+	scalar[0] &= 248
+	scalar[31] = scalar[31]&127 | 64
+
+	private, err := ecdh.X25519().NewPrivateKey(scalar)
+	if err != nil {
+		return KeyPair{}, err
+	}
+
+	return KeyPair{
+		Kind:    KindWireGuard,
+		Private: []byte(base64.StdEncoding.EncodeToString(private.Bytes()) + "\n"),
+		Public:  base64.StdEncoding.EncodeToString(private.PublicKey().Bytes()),
+	}, nil
+}
+
+// an identity file as age-keygen writes it, the recipient is the public key
+func Age(collector *entropy.Collector) (KeyPair, error) {
+	private, err := ecdh.X25519().NewPrivateKey(seed(collector, 32))
+	if err != nil {
+		return KeyPair{}, err
+	}
+
+	identity, err := bech32Encode("age-secret-key-", private.Bytes())
+	if err != nil {
+		return KeyPair{}, err
+	}
+	recipient, err := bech32Encode("age", private.PublicKey().Bytes())
+	if err != nil {
+		return KeyPair{}, err
+	}
+
+	file := fmt.Sprintf("# created: %s\n# public key: %s\n%s\n",
+		time.Now().Format(time.RFC3339), recipient, strings.ToUpper(identity))
+	return KeyPair{Kind: KindAge, Private: []byte(file), Public: recipient}, nil
+}
@@ -0,0 +1,222 @@
+package password
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/nbutton23/zxcvbn-go"
+)
+
+// judges a password, each implementation fills the fields it knows about
+type Analyzer interface {
+	Analyze(password string) PasswordStrength
+}
+
+// zxcvbn's pattern matching, the only analyzer that scores
+// user inputs are words an attacker knows to try first: the username,
+// the company, the site
+type ZxcvbnAnalyzer struct {
+	UserInputs []string
+	Context    func() []string // more inputs looked up per password, e.g. Generator.GetUserInputs
+}
+
+func (a ZxcvbnAnalyzer) Analyze(password string) PasswordStrength {
+	inputs := a.UserInputs
+	if a.Context != nil {
+		inputs = append(slices.Clip(inputs), a.Context()...)
+	}
+	result := zxcvbn.PasswordStrength(password, inputs)
+
+	// feedback based on score
+	feedback := ""
+	if result.Score < 3 {
+		if len(password) < 12 {
+			feedback = "Consider a longer password"
+		} else {
+			feedback = "Try adding more varied characters"
+		}
+	}
+
+	strength := PasswordStrength{
+		Scored:           true,
+		Score:            result.Score,
+		EntropyBits:      float64(result.Entropy),
+		CrackTimeDesc:    result.CrackTimeDisplay,
+		CrackTimeSeconds: result.CrackTime,
+		Feedback:         feedback,
+	}
+
+	// the token is the input as typed, lowercased it is the word itself
+	for _, match := range result.MatchSequence {
+		word := strings.ToLower(match.Token)
+		if match.DictionaryName == userInputsDictionary && !slices.Contains(strength.UserMatches, word) {
+			strength.UserMatches = append(strength.UserMatches, word)
+		}
+	}
+	if len(strength.UserMatches) > 0 {
+		strength.UserPenalty = zxcvbn.PasswordStrength(password, nil).Entropy - strength.EntropyBits
+	}
+
+	return strength
+}
+
+// zxcvbn's name for the dictionary built from user inputs
+const userInputsDictionary = "user_inputs"
+
+// one word per line, blank lines and # comments skipped
+func LoadUserInputs(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var words []string
+	for _, line := range strings.Split(string(content), "\n") {
+		word := strings.TrimSpace(line)
+		if word != "" && !strings.HasPrefix(word, "#") {
+			words = append(words, word)
+		}
+	}
+	return words, nil
+}
+
+// entropy of the generator's current settings, for passwords they can produce
+// the password Generate returned last keeps the entropy of the settings
+// that made it, so later setting changes don't relabel it
+type ExactAnalyzer struct {
+	generator *Generator
+}
+
+func NewExactAnalyzer(generator *Generator) *ExactAnalyzer {
+	return &ExactAnalyzer{generator: generator}
+}
+
+func (a *ExactAnalyzer) Analyze(password string) PasswordStrength {
+	if generated := a.generator.generated; generated.password == password && password != "" {
+		return PasswordStrength{Exact: generated.exact}
+	}
+
+	// other modes have no cheap membership test, their passwords are trusted
+	if a.generator.GetMode() == ModeCharacters && len(a.generator.activePolicy().Violations(password)) > 0 {
+		return PasswordStrength{}
+	}
+	return PasswordStrength{Exact: a.generator.CaptureExact()}
+}
+
+// policy and pwquality rules, nil ones are skipped
+type RuleAnalyzer struct {
+	Policy  *Policy
+	Quality *PWQuality
+}
+
+func (a RuleAnalyzer) Analyze(password string) PasswordStrength {
+	var violations []string
+	if a.Policy != nil {
+		violations = append(violations, a.Policy.Violations(password)...)
+	}
+	if a.Quality != nil {
+		violations = append(violations, a.Quality.Check(password)...)
+	}
+	return PasswordStrength{Violations: violations}
+}
+
+type combinedAnalyzer []Analyzer
+
+// runs every analyzer and merges what they report, the most pessimistic
+// score wins and violations accumulate
+func Combine(analyzers ...Analyzer) Analyzer {
+	return combinedAnalyzer(analyzers)
+}
+
+func (c combinedAnalyzer) Analyze(password string) PasswordStrength {
+	var merged PasswordStrength
+	for _, analyzer := range c {
+		merged = merged.merge(analyzer.Analyze(password))
+	}
+	return merged
+}
+
+func (s PasswordStrength) merge(other PasswordStrength) PasswordStrength {
+	if other.Scored && (!s.Scored || other.Score < s.Score ||
+		(other.Score == s.Score && other.EntropyBits < s.EntropyBits)) {
+		s.Scored = true
+		s.Score = other.Score
+		s.EntropyBits = other.EntropyBits
+		s.CrackTimeDesc = other.CrackTimeDesc
+		s.CrackTimeSeconds = other.CrackTimeSeconds
+		s.UserMatches = other.UserMatches
+		s.UserPenalty = other.UserPenalty
+	}
+
+	if other.Exact.Known {
+		s.Exact = other.Exact
+	}
+	if other.Blacklisted != "" {
+		s.Blacklisted = other.Blacklisted
+	}
+	s.BreachChecked = s.BreachChecked || other.BreachChecked
+	s.BreachCount = max(s.BreachCount, other.BreachCount)
+	s.Violations = append(s.Violations, other.Violations...)
+
+	if other.Feedback != "" && !strings.Contains(s.Feedback, other.Feedback) {
+		if s.Feedback != "" {
+			s.Feedback += "; "
+		}
+		s.Feedback += other.Feedback
+	}
+
+	return s
+}
+
+// remembers the latest results so redraws don't rerun zxcvbn
+// the oldest entry is dropped once the cache is full
+type CachedAnalyzer struct {
+	analyzer Analyzer
+	size     int
+	version  func() uint64 // settings the results depend on, nil when fixed
+
+	mu      sync.Mutex
+	results map[cacheKey]PasswordStrength
+	order   []cacheKey
+}
+
+type cacheKey struct {
+	version  uint64
+	password string
+}
+
+// version keys the results on the settings the analyzers read, e.g.
+// Generator.SettingsVersion, so a profile or mode change isn't served
+// stale results; nil when the analyzers don't depend on any
+func NewCachedAnalyzer(analyzer Analyzer, size int, version func() uint64) *CachedAnalyzer {
+	return &CachedAnalyzer{
+		analyzer: analyzer,
+		size:     max(size, 1),
+		version:  version,
+		results:  make(map[cacheKey]PasswordStrength),
+	}
+}
+
+func (c *CachedAnalyzer) Analyze(password string) PasswordStrength {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := cacheKey{password: password}
+	if c.version != nil {
+		key.version = c.version()
+	}
+	if result, ok := c.results[key]; ok {
+		return result
+	}
+
+	result := c.analyzer.Analyze(password)
+	if len(c.order) == c.size {
+		delete(c.results, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.results[key] = result
+	c.order = append(c.order, key)
+	return result
+}
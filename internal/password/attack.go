@@ -0,0 +1,418 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"datflux/internal/entropy"
+)
+
+// how fast an attacker guesses, built in or from attack-models.conf
+type AttackModel struct {
+	Name          string
+	Description   string
+	GuessesPerSec float64
+	Quantum       *QuantumParams // Grover's search, nil for classical models
+
+	Hash  HashParams // the stored hash, empty for the built-in models
+	GPUs  int        // 0 when the attack isn't bought by the GPU-hour
+	Price PriceTable
+}
+
+// the built-in models, indexes into GetAttackModels
+type AttackModelType int
+
+const (
+	OnlineRateLimited  AttackModelType = iota // default zxcvbn (100 guesses/sec)
+	OfflineGPUCracking                        // serious offline attack (1 billion guesses/sec)
+	QuantumComputing                          // Grover's search, see QuantumParams
+)
+
+func GetAttackModels() []AttackModel {
+	return []AttackModel{
+		{
+			Name:          "Online Rate-Limited",
+			Description:   "Standard online attack with rate limiting (100 guesses/sec)",
+			GuessesPerSec: 100, // 10ms per guess
+		},
+		{
+			Name:          "Offline GPU Cracking",
+			Description:   "Serious password file breach (1 billion guesses/sec)",
+			GuessesPerSec: 1e9, // 1 billion
+			GPUs:          1,
+			Price:         DefaultPrices(),
+		},
+		quantumModel("Quantum Computing", "State-level adversary running Grover's search", DefaultQuantumParams()),
+	}
+}
+
+// GuessesPerSec counts Grover iterations across every machine, the crack
+// time comes from the parameters instead
+func quantumModel(name, description string, params QuantumParams) AttackModel {
+	return AttackModel{
+		Name:          name,
+		Description:   description,
+		GuessesPerSec: params.IterationsPerSec() * params.Machines,
+		Quantum:       &params,
+	}
+}
+
+// guess rate for listings, Grover's iterations for quantum models
+func (m AttackModel) RateString() string {
+	if m.Quantum != nil {
+		return m.Quantum.String()
+	}
+	return FormatRate(m.GuessesPerSec)
+}
+
+// seconds to crack from zxcvbn's entropy estimate, analyze the password
+// first; its own CrackTimeSeconds assumes a fixed 10,000 guesses/s, so the
+// time is worked out from the bits like CrackSecondsForEntropy does
+func CrackSecondsForModel(strength PasswordStrength, model AttackModel) float64 {
+	// Grover's search works on the entropy, the exact figure when known
+	// so passphrases aren't overrated
+	if model.Quantum != nil && strength.Exact.Known {
+		return CrackSecondsForEntropy(strength.Exact.Bits, model)
+	}
+	return CrackSecondsForEntropy(strength.EntropyBits, model)
+}
+
+// seconds to crack from an exact entropy figure instead of zxcvbn's estimate
+// on average half the space has to be searched
+func CrackSecondsForEntropy(entropyBits float64, model AttackModel) float64 {
+	if model.Quantum != nil {
+		return model.Quantum.Seconds(entropyBits)
+	}
+
+	return 0.5 * math.Pow(2, entropyBits) / model.GuessesPerSec
+}
+
+// attack models and per-GPU rates, <config dir>/attack-models.conf
+//
+//	[rates]
+//	bcrypt = 184000
+//
+//	[prices]
+//	gpu_hour = 0.40
+//	gpu_watts = 450
+//
+//	[Leaked bcrypt dump]
+//	hash = bcrypt
+//	cost = 12
+//	gpus = 8
+//
+//	[Rented rig vs NTLM]
+//	hash = ntlm
+//	gpus = 64
+//	rate = 250e9
+//
+//	[quantum]
+//	logical_hz = 1e8
+//	iteration_depth = 1e4
+//	machines = 1e6
+//	target_years = 100
+//
+// [rates] overrides the per-GPU reference rates by algorithm, which is
+// where `datflux models import` writes hashcat benchmarks; [prices] sets
+// the dollars per GPU-hour and watts per GPU every GPU model is costed at;
+// [quantum] tunes the built-in Grover model; every other section is a
+// model, rate (per GPU) wins over the hash's rate, gpu_hour or gpu_watts
+// override the prices, and the [quantum] keys make it a quantum model
+func AttackModelsPath() string {
+	return filepath.Join(entropy.ConfigDir(), "attack-models.conf")
+}
+
+// sections of attack-models.conf that aren't models
+const (
+	ratesSection   = "rates"
+	pricesSection  = "prices"
+	quantumSection = "quantum"
+)
+
+func isSettingsSection(name string) bool {
+	return name == "" || name == ratesSection || name == pricesSection || name == quantumSection
+}
+
+// the built-in models followed by the configured ones, a missing file
+// leaves just the built-ins
+func LoadAttackModels(path string) ([]AttackModel, error) {
+	models := GetAttackModels()
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return models, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sections, err := parseINI(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	rates := DefaultHashRates()
+	prices := DefaultPrices()
+	quantum := DefaultQuantumParams()
+	for _, section := range sections {
+		for _, entry := range section.Entries {
+			var err error
+			switch section.Name {
+			case pricesSection:
+				err = prices.set(entry.Key, entry.Value)
+			case quantumSection:
+				err = quantum.set(entry.Key, entry.Value)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, entry.Line, err)
+			}
+		}
+		if section.Name != ratesSection {
+			continue
+		}
+		for _, entry := range section.Entries {
+			if _, ok := lookupHashAlgorithm(entry.Key); !ok {
+				return nil, fmt.Errorf("%s:%d: unknown hash %q", path, entry.Line, entry.Key)
+			}
+			rate, err := parseRate(entry.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %s: %w", path, entry.Line, entry.Key, err)
+			}
+			rates[entry.Key] = rate
+		}
+	}
+
+	if err := quantum.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: [%s] %w", path, quantumSection, err)
+	}
+
+	for i, model := range models {
+		switch {
+		case model.GPUs > 0:
+			models[i].Price = prices
+		case model.Quantum != nil:
+			models[i] = quantumModel(model.Name, model.Description, quantum)
+		}
+	}
+
+	for _, section := range sections {
+		if isSettingsSection(section.Name) {
+			continue
+		}
+
+		model, err := parseAttackModel(section, rates, prices, quantum)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		models = append(models, model)
+	}
+
+	return models, nil
+}
+
+func parseAttackModel(section iniSection, rates HashRates, prices PriceTable, quantum QuantumParams) (AttackModel, error) {
+	model := AttackModel{Name: section.Name, GPUs: 1, Price: prices}
+	var perGPU float64
+	isQuantum := false
+
+	for _, entry := range section.Entries {
+		var err error
+		switch entry.Key {
+		case "hash":
+			model.Hash.Algorithm = strings.ToLower(entry.Value)
+		case "cost":
+			err = parseProfileIntInto(entry.Key, entry.Value, &model.Hash.Cost)
+		case "rounds":
+			err = parseProfileIntInto(entry.Key, entry.Value, &model.Hash.Rounds)
+		case "memory":
+			err = parseProfileIntInto(entry.Key, entry.Value, &model.Hash.MemoryKB)
+		case "iterations":
+			err = parseProfileIntInto(entry.Key, entry.Value, &model.Hash.Iterations)
+		case "parallelism":
+			err = parseProfileIntInto(entry.Key, entry.Value, &model.Hash.Parallelism)
+		case "gpus":
+			err = parseProfileIntInto(entry.Key, entry.Value, &model.GPUs)
+		case "rate":
+			if perGPU, err = parseRate(entry.Value); err != nil {
+				err = fmt.Errorf("%s: %w", entry.Key, err)
+			}
+		case "description":
+			model.Description = entry.Value
+		case "gpu_hour", "gpu_watts":
+			err = model.Price.set(entry.Key, entry.Value)
+		case "logical_hz", "iteration_depth", "machines", "target_years":
+			isQuantum = true
+			err = quantum.set(entry.Key, entry.Value)
+		default:
+			err = fmt.Errorf("unknown attack model key %q", entry.Key)
+		}
+		if err != nil {
+			return AttackModel{}, fmt.Errorf("line %d: %w", entry.Line, err)
+		}
+	}
+
+	if isQuantum {
+		if model.Hash.Algorithm != "" || perGPU != 0 {
+			return AttackModel{}, fmt.Errorf("model %q mixes quantum keys with hash or rate", model.Name)
+		}
+		if err := quantum.Validate(); err != nil {
+			return AttackModel{}, fmt.Errorf("model %q: %w", model.Name, err)
+		}
+		if model.Description == "" {
+			model.Description = "Custom adversary running Grover's search"
+		}
+		return quantumModel(model.Name, model.Description, quantum), nil
+	}
+
+	switch {
+	case model.GPUs < 1:
+		return AttackModel{}, fmt.Errorf("model %q: gpus must be at least 1", model.Name)
+	case model.Hash.Algorithm == "" && perGPU == 0:
+		return AttackModel{}, fmt.Errorf("model %q needs a hash or a rate", model.Name)
+	}
+	if model.Hash.Algorithm != "" {
+		if err := model.Hash.Validate(); err != nil {
+			return AttackModel{}, fmt.Errorf("model %q: %w", model.Name, err)
+		}
+		model.Hash = model.Hash.withDefaults()
+		if perGPU == 0 {
+			perGPU = model.Hash.Rate(rates)
+		}
+	}
+	model.GuessesPerSec = perGPU * float64(model.GPUs)
+
+	if model.Description == "" {
+		model.Description = model.describe(perGPU)
+	}
+	return model, nil
+}
+
+// e.g. "bcrypt cost 12 on 8 GPUs at 5.75 kH/s each"
+func (m AttackModel) describe(perGPU float64) string {
+	target := "custom hash"
+	if m.Hash.Algorithm != "" {
+		target = m.Hash.String()
+	}
+
+	gpus := "1 GPU"
+	if m.GPUs != 1 {
+		gpus = fmt.Sprintf("%d GPUs", m.GPUs)
+	}
+	return fmt.Sprintf("%s on %s at %s each", target, gpus, FormatRate(perGPU))
+}
+
+func (p *PriceTable) set(key, value string) error {
+	amount, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || amount < 0 || math.IsInf(amount, 0) {
+		return fmt.Errorf("%s: %q is not a price or wattage", key, value)
+	}
+
+	switch key {
+	case "gpu_hour":
+		p.GPUHour = amount
+	case "gpu_watts":
+		p.GPUWatts = amount
+	default:
+		return fmt.Errorf("unknown price key %q (gpu_hour, gpu_watts)", key)
+	}
+	return nil
+}
+
+// guesses per second, plain or with an exponent (164.1e9)
+func parseRate(value string) (float64, error) {
+	rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || rate <= 0 || math.IsInf(rate, 0) {
+		return 0, fmt.Errorf("%q is not a positive rate", value)
+	}
+	return rate, nil
+}
+
+// writes a hashcat benchmark's rates into [rates], keeping the models
+func ImportHashcatBenchmark(path string, benchmark io.Reader) (HashRates, error) {
+	rates, err := ParseHashcatBenchmark(benchmark)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	updated := string(content)
+	for _, algorithm := range hashAlgorithms {
+		if rate, ok := rates[algorithm.name]; ok {
+			updated = setINIValue(updated, ratesSection, algorithm.name, strconv.FormatFloat(rate, 'g', 6, 64))
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	return rates, os.WriteFile(path, []byte(updated), 0600)
+}
+
+// descriptions based on crack time
+func GetCrackTimeDescription(seconds float64) string {
+	// time constants
+	minute := float64(60)
+	hour := minute * 60
+	day := hour * 24
+	month := day * 30
+	year := day * 365
+	decade := year * 10
+	century := year * 100
+
+	universeAge := 13.8 * 1e9 * year
+
+	switch {
+	case seconds < 0.001:
+		return "instant"
+	case seconds < 1:
+		return "< 1 second"
+	case seconds < minute:
+		return fmt.Sprintf("%d seconds", int(seconds))
+	case seconds < hour:
+		return fmt.Sprintf("%d minutes", int(seconds/minute))
+	case seconds < day:
+		return fmt.Sprintf("%d hours", int(seconds/hour))
+	case seconds < 7*day:
+		return fmt.Sprintf("%d days", int(seconds/day))
+	case seconds < month:
+		return fmt.Sprintf("%d weeks", int(seconds/(7*day)))
+	case seconds < year:
+		return fmt.Sprintf("%d months", int(seconds/month))
+	case seconds < decade:
+		return fmt.Sprintf("%d years", int(seconds/year))
+	case seconds < century:
+		return fmt.Sprintf("%d decades", int(seconds/decade))
+	case seconds < 10*century:
+		return fmt.Sprintf("%d centuries", int(seconds/century))
+	case seconds < universeAge:
+		billionYears := seconds / year / 1e9
+
+		if billionYears < 0.1 {
+			millennia := int(seconds / (10 * century))
+			return fmt.Sprintf("%d millennia", millennia)
+		}
+
+		return fmt.Sprintf("%.1f billion years", billionYears)
+	default:
+		cosmicScale := seconds / universeAge
+		if cosmicScale < 1000 {
+			// at least 1.1x, to avoid strange values
+			if cosmicScale < 1.1 {
+				return "the age of the universe"
+			}
+			return fmt.Sprintf("%.1f× the age of the universe", cosmicScale)
+		}
+		return "until the heat death of the universe"
+	}
+}
@@ -0,0 +1,175 @@
+package password
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// shorter words would reject nearly every random string
+const minBlacklistWord = 4
+
+// redraws before Generate gives up with an error
+const maxBlacklistAttempts = 1000
+
+// dictionary words and banned terms a password may not contain, in plain,
+// leetspeak or reversed form, cracklib style
+// leetspeak is matched by folding look-alikes together (0 and o, 1 l and i,
+// @ 4 and a...), so words that differ only in those also match
+type Blacklist struct {
+	words   map[string]string // folded form to the word as listed
+	longest int
+}
+
+// what Match found, the word is part of the password, show it with care
+type BlacklistMatch struct {
+	Word     string
+	Leet     bool
+	Reversed bool
+}
+
+func (m BlacklistMatch) String() string {
+	var forms []string
+	if m.Leet {
+		forms = append(forms, "leetspeak")
+	}
+	if m.Reversed {
+		forms = append(forms, "reversed")
+	}
+	if len(forms) == 0 {
+		return m.Word
+	}
+	return fmt.Sprintf("%s (%s)", m.Word, strings.Join(forms, ", "))
+}
+
+// one word per line from each file, e.g. /usr/share/dict/words and a
+// company banned list, blank lines, # comments and short words are skipped
+func LoadBlacklist(paths ...string) (*Blacklist, error) {
+	blacklist := &Blacklist{words: make(map[string]string)}
+
+	for _, path := range paths {
+		words, err := LoadUserInputs(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, word := range words {
+			blacklist.add(word)
+		}
+	}
+
+	if len(blacklist.words) == 0 {
+		return nil, fmt.Errorf("no words of %d or more characters in %s", minBlacklistWord, strings.Join(paths, ", "))
+	}
+	return blacklist, nil
+}
+
+func (b *Blacklist) add(word string) {
+	word = strings.ToLower(word)
+	folded := foldLeet([]rune(word))
+	if len(folded) < minBlacklistWord {
+		return
+	}
+	if _, exists := b.words[string(folded)]; !exists {
+		b.words[string(folded)] = word
+	}
+	b.longest = max(b.longest, len(folded))
+}
+
+func (b *Blacklist) Len() int {
+	return len(b.words)
+}
+
+// the longest blacklisted word in the password, forwards or backwards
+func (b *Blacklist) Match(password string) (BlacklistMatch, bool) {
+	plain := []rune(strings.ToLower(password))
+	folded := foldLeet(plain)
+
+	for length := min(b.longest, len(folded)); length >= minBlacklistWord; length-- {
+		for start := 0; start+length <= len(folded); start++ {
+			window := folded[start : start+length]
+			literal := plain[start : start+length]
+
+			if word, ok := b.words[string(window)]; ok {
+				return BlacklistMatch{Word: word, Leet: string(literal) != word}, true
+			}
+
+			reversed := slices.Clone(window)
+			slices.Reverse(reversed)
+			if word, ok := b.words[string(reversed)]; ok {
+				literal = slices.Clone(literal)
+				slices.Reverse(literal)
+				return BlacklistMatch{Word: word, Leet: string(literal) != word, Reversed: true}, true
+			}
+		}
+	}
+	return BlacklistMatch{}, false
+}
+
+// look-alike digits and symbols mapped to letters, runes are lowercase already
+var leetFolds = map[rune]rune{
+	'0': 'o',
+	'1': 'i', 'l': 'i', '!': 'i', '|': 'i',
+	'3': 'e',
+	'4': 'a', '@': 'a',
+	'5': 's', '$': 's',
+	'7': 't', '+': 't',
+	'8': 'b',
+	'9': 'g',
+}
+
+func foldLeet(runes []rune) []rune {
+	folded := make([]rune, len(runes))
+	for i, char := range runes {
+		if replacement, ok := leetFolds[char]; ok {
+			char = replacement
+		}
+		folded[i] = char
+	}
+	return folded
+}
+
+// draws and rejections since the blacklist was set
+type BlacklistStats struct {
+	Drawn    int64
+	Rejected int64
+}
+
+// bits the rejections remove: dropping a share r of a uniform space
+// leaves log2(1/(1-r)) fewer bits, estimated from the observed rate
+func (s BlacklistStats) EntropyCost() float64 {
+	if s.Drawn == 0 || s.Rejected >= s.Drawn {
+		return 0
+	}
+	return math.Log2(1 / (1 - float64(s.Rejected)/float64(s.Drawn)))
+}
+
+type blacklistCounters struct {
+	drawn    atomic.Int64
+	rejected atomic.Int64
+}
+
+// flags passwords containing a blacklisted word, the violation doesn't
+// name it so reports never leak part of a password
+type BlacklistAnalyzer struct {
+	Blacklist *Blacklist
+}
+
+func (a BlacklistAnalyzer) Analyze(password string) PasswordStrength {
+	match, found := a.Blacklist.Match(password)
+	if !found {
+		return PasswordStrength{}
+	}
+
+	violation := "contains a blacklisted word"
+	switch {
+	case match.Leet && match.Reversed:
+		violation += " in reversed leetspeak"
+	case match.Leet:
+		violation += " in leetspeak"
+	case match.Reversed:
+		violation += " reversed"
+	}
+	return PasswordStrength{Blacklisted: match.String(), Violations: []string{violation}}
+}
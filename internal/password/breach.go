@@ -0,0 +1,269 @@
+package password
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1" // nosec G505 -- HIBP publishes SHA-1, nothing is hashed for storage
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"datflux/internal/entropy"
+)
+
+// returned when an index file is not one BuildBreachIndex wrote
+var ErrBadBreachIndex = errors.New("not a datflux breach index")
+
+// index layout: the magic, then sorted records of a SHA-1 and a big-endian
+// uint32 count, so a lookup is a binary search of 24-byte reads
+const (
+	breachMagic      = "DFXHIBP1"
+	breachRecordSize = sha1.Size + 4
+)
+
+// where `datflux breach index` writes and the TUI and check look by default
+func BreachIndexPath() string {
+	return filepath.Join(entropy.ConfigDir(), "hibp.idx")
+}
+
+// an open index, lookups read the file and never load it whole
+type BreachIndex struct {
+	file    *os.File
+	records int64
+}
+
+func OpenBreachIndex(path string) (*BreachIndex, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	magic := make([]byte, len(breachMagic))
+	size := info.Size() - int64(len(breachMagic))
+	if _, err := file.ReadAt(magic, 0); err != nil || string(magic) != breachMagic || size%breachRecordSize != 0 {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", path, ErrBadBreachIndex)
+	}
+
+	return &BreachIndex{file: file, records: size / breachRecordSize}, nil
+}
+
+func (b *BreachIndex) Close() error {
+	return b.file.Close()
+}
+
+func (b *BreachIndex) Len() int64 {
+	return b.records
+}
+
+// times the password appears in the dump, 0 when it doesn't
+func (b *BreachIndex) Lookup(password string) (int, error) {
+	hash := sha1.Sum([]byte(password)) // nosec G401 -- matching HIBP's format
+	record := make([]byte, breachRecordSize)
+
+	var readErr error
+	read := func(i int64) []byte {
+		offset := int64(len(breachMagic)) + i*breachRecordSize
+		if _, err := b.file.ReadAt(record, offset); err != nil && readErr == nil {
+			readErr = err
+		}
+		return record
+	}
+
+	i := int64(sort.Search(int(b.records), func(i int) bool {
+		return bytes.Compare(read(int64(i))[:sha1.Size], hash[:]) >= 0
+	}))
+	if i == b.records {
+		return 0, readErr
+	}
+
+	found := bytes.Equal(read(i)[:sha1.Size], hash[:])
+	if readErr != nil || !found {
+		return 0, readErr
+	}
+	return int(binary.BigEndian.Uint32(record[sha1.Size:])), nil
+}
+
+// builds an index from HIBP's SHA-1 downloads, streamed in one pass:
+// the full dump or any sorted list (HASH or HASH:COUNT per line), or a
+// directory of range files named by their five-character prefix
+// (00000.txt holding SUFFIX:COUNT lines, as the downloader writes them)
+// input must already be sorted by hash, as HIBP ships it
+func BuildBreachIndex(w io.Writer, sources []string) (int64, error) {
+	out := bufio.NewWriter(w)
+	if _, err := out.WriteString(breachMagic); err != nil {
+		return 0, err
+	}
+
+	builder := breachBuilder{out: out}
+	for _, source := range sources {
+		if err := builder.addSource(source); err != nil {
+			return builder.written, err
+		}
+	}
+	if err := builder.flush(); err != nil {
+		return builder.written, err
+	}
+	return builder.written, out.Flush()
+}
+
+type breachBuilder struct {
+	out     *bufio.Writer
+	pending []byte // last hash, held back so duplicates can be summed
+	count   uint64
+	written int64
+}
+
+func (b *breachBuilder) addSource(source string) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return b.addFile(source, "")
+	}
+
+	entries, err := os.ReadDir(source)
+	if err != nil {
+		return err
+	}
+	// ReadDir sorts by name, so range files come in hash order
+	for _, entry := range entries {
+		prefix := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if entry.IsDir() || len(prefix) != 5 {
+			continue
+		}
+		if _, err := hex.DecodeString(prefix + "0"); err != nil {
+			continue
+		}
+		if err := b.addFile(filepath.Join(source, entry.Name()), prefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *breachBuilder) addFile(path, prefix string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	lineNumber := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		hash, count, err := parseBreachLine(prefix, line)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		if err := b.add(hash, count); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// HASH, HASH:COUNT, or SUFFIX:COUNT inside a range file
+func parseBreachLine(prefix, line string) ([]byte, uint64, error) {
+	hexHash, countText, hasCount := strings.Cut(line, ":")
+	hexHash = prefix + hexHash
+
+	switch len(hexHash) {
+	case 2 * sha1.Size:
+	case 32:
+		return nil, 0, fmt.Errorf("NTLM hashes aren't supported, download the SHA-1 list")
+	default:
+		return nil, 0, fmt.Errorf("%q is not a SHA-1 hash", hexHash)
+	}
+
+	hash, err := hex.DecodeString(hexHash)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%q is not a SHA-1 hash", hexHash)
+	}
+
+	count := uint64(1)
+	if hasCount {
+		if count, err = strconv.ParseUint(strings.TrimSpace(countText), 10, 64); err != nil {
+			return nil, 0, fmt.Errorf("%q is not a count", countText)
+		}
+	}
+	return hash, count, nil
+}
+
+func (b *breachBuilder) add(hash []byte, count uint64) error {
+	if b.pending != nil {
+		switch bytes.Compare(b.pending, hash) {
+		case 0:
+			b.count += count
+			return nil
+		case 1:
+			return fmt.Errorf("hashes are not sorted, sort the list by hash first")
+		}
+		if err := b.flush(); err != nil {
+			return err
+		}
+	}
+
+	b.pending, b.count = hash, count
+	return nil
+}
+
+func (b *breachBuilder) flush() error {
+	if b.pending == nil {
+		return nil
+	}
+
+	record := make([]byte, breachRecordSize)
+	copy(record, b.pending)
+	binary.BigEndian.PutUint32(record[sha1.Size:], uint32(min(max(b.count, 1), math.MaxUint32)))
+	if _, err := b.out.Write(record); err != nil {
+		return err
+	}
+
+	b.written++
+	b.pending = nil
+	return nil
+}
+
+// flags passwords found in a breach index, a lookup that fails counts as a
+// violation so gating scripts never pass on a broken index
+type BreachAnalyzer struct {
+	Index *BreachIndex
+}
+
+func (a BreachAnalyzer) Analyze(password string) PasswordStrength {
+	count, err := a.Index.Lookup(password)
+	if err != nil {
+		return PasswordStrength{Violations: []string{fmt.Sprintf("breach lookup failed: %v", err)}}
+	}
+	if count == 0 {
+		return PasswordStrength{BreachChecked: true}
+	}
+
+	return PasswordStrength{
+		BreachChecked: true,
+		BreachCount:   count,
+		Violations:    []string{fmt.Sprintf("appears %d times in breached password lists", count)},
+	}
+}
@@ -0,0 +1,176 @@
+package password
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// one character class of an alphabet, e.g. the digits
+type CharClass struct {
+	Name  string
+	Chars string
+	Min   int // chars of this class every password must contain
+}
+
+// alphabet split into the four classes a Policy can toggle
+// empty classes simply don't exist in that charset (hex has no uppercase)
+type Charset struct {
+	Name        string
+	Description string
+	Lower       string
+	Upper       string
+	Digits      string
+	Symbols     string
+}
+
+const (
+	lowerChars  = "abcdefghijklmnopqrstuvwxyz"
+	upperChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars  = "0123456789"
+	symbolChars = "!@#$%^&*()_+-=[]{}|;:,.<>?/"
+)
+
+const DefaultCharsetName = "default"
+
+var charsetPresets = []Charset{
+	{
+		Name:        DefaultCharsetName,
+		Description: "letters, digits and common symbols",
+		Lower:       lowerChars,
+		Upper:       upperChars,
+		Digits:      digitChars,
+		Symbols:     symbolChars,
+	},
+	{
+		Name:        "alnum",
+		Description: "letters and digits only",
+		Lower:       lowerChars,
+		Upper:       upperChars,
+		Digits:      digitChars,
+	},
+	{
+		Name:        "no-ambiguous",
+		Description: "drops 0O1lI| and other look-alikes",
+		Lower:       "abcdefghijkmnopqrstuvwxyz",
+		Upper:       "ABCDEFGHJKLMNPQRSTUVWXYZ",
+		Digits:      "23456789",
+		Symbols:     "!@#$%^&*()_+-=[]{};:,.<>?/",
+	},
+	{
+		Name:        "shell-safe",
+		Description: "symbols that need no quoting in POSIX shells",
+		Lower:       lowerChars,
+		Upper:       upperChars,
+		Digits:      digitChars,
+		Symbols:     "%+,-./:=@_",
+	},
+	{
+		Name:        "url-safe",
+		Description: "RFC 3986 unreserved characters",
+		Lower:       lowerChars,
+		Upper:       upperChars,
+		Digits:      digitChars,
+		Symbols:     "-._~",
+	},
+	{
+		Name:        "xml-safe",
+		Description: "no &, <, >, quotes or backslashes",
+		Lower:       lowerChars,
+		Upper:       upperChars,
+		Digits:      digitChars,
+		Symbols:     "!#$%()*+,-./:;=?@[]^_{|}~",
+	},
+	{
+		Name:        "hex",
+		Description: "lowercase hexadecimal",
+		Lower:       "abcdef",
+		Digits:      digitChars,
+	},
+	{
+		Name:        "base32",
+		Description: "RFC 4648 base32 alphabet",
+		Upper:       upperChars,
+		Digits:      "234567",
+	},
+}
+
+func LookupCharset(name string) (Charset, bool) {
+	for _, preset := range charsetPresets {
+		if preset.Name == name {
+			return preset, true
+		}
+	}
+	return Charset{}, false
+}
+
+func CharsetPresets() []Charset {
+	return slices.Clone(charsetPresets)
+}
+
+// literal alphabet, each character sorted into its class
+func CustomCharset(chars string) Charset {
+	custom := Charset{
+		Name:        "custom",
+		Description: "user-supplied alphabet",
+	}
+
+	for _, char := range uniqueASCII(chars) {
+		switch {
+		case 'a' <= char && char <= 'z':
+			custom.Lower += string(char)
+		case 'A' <= char && char <= 'Z':
+			custom.Upper += string(char)
+		case '0' <= char && char <= '9':
+			custom.Digits += string(char)
+		default:
+			custom.Symbols += string(char)
+		}
+	}
+
+	return custom
+}
+
+// lower, upper, digit or other, the split libpwquality and CustomCharset use
+func classOf(char rune) int {
+	switch {
+	case 'a' <= char && char <= 'z':
+		return 0
+	case 'A' <= char && char <= 'Z':
+		return 1
+	case '0' <= char && char <= '9':
+		return 2
+	default:
+		return 3
+	}
+}
+
+// printable ASCII only, in order of first appearance
+func uniqueASCII(chars string) string {
+	var builder strings.Builder
+	seen := make(map[rune]bool)
+
+	for _, char := range chars {
+		if char > unicode.MaxASCII || !unicode.IsPrint(char) || char == ' ' || seen[char] {
+			continue
+		}
+		seen[char] = true
+		builder.WriteRune(char)
+	}
+
+	return builder.String()
+}
+
+func removeChars(set, exclude string) string {
+	if exclude == "" {
+		return set
+	}
+
+	var builder strings.Builder
+	for _, char := range set {
+		if !strings.ContainsRune(exclude, char) {
+			builder.WriteRune(char)
+		}
+	}
+	return builder.String()
+}
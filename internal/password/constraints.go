@@ -0,0 +1,585 @@
+package password
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// US QWERTY rows, unshifted and shifted, for keyboard walk detection
+var keyboardRows = []string{
+	"`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", "zxcvbnm,./",
+	"~!@#$%^&*()_+", "QWERTYUIOP{}|", "ASDFGHJKL:\"", "ZXCVBNM<>?",
+}
+
+type keyPosition struct {
+	row, column int
+}
+
+var keyboardPositions = func() map[rune]keyPosition {
+	positions := make(map[rune]keyPosition)
+	for row, keys := range keyboardRows {
+		for column, key := range keys {
+			// shifted rows share the physical row of their unshifted twin
+			positions[key] = keyPosition{row % 4, column}
+		}
+	}
+	return positions
+}()
+
+// ways one char can continue a sequence from the previous one
+const (
+	stepAscending = iota
+	stepDescending
+	stepKeyRight
+	stepKeyLeft
+	stepKinds
+)
+
+// which step kinds lead from previous to next
+// ascending and descending runs only count within letters or digits
+func sequenceSteps(previous, next rune) [stepKinds]bool {
+	var steps [stepKinds]bool
+
+	if class := classOf(previous); class == classOf(next) && class != 3 {
+		steps[stepAscending] = next == previous+1
+		steps[stepDescending] = next == previous-1
+	}
+
+	from, fromOK := keyboardPositions[previous]
+	to, toOK := keyboardPositions[next]
+	if fromOK && toOK && from.row == to.row {
+		steps[stepKeyRight] = to.column == from.column+1
+		steps[stepKeyLeft] = to.column == from.column-1
+	}
+
+	return steps
+}
+
+// longest ascending, descending or keyboard run, e.g. "abcd", "4321", "qwer"
+func longestSequence(password string) int {
+	if password == "" {
+		return 0
+	}
+
+	longest := 1
+	var lengths [stepKinds]int
+	previous := rune(-1)
+
+	for _, char := range password {
+		steps := sequenceSteps(previous, char)
+		for kind := range lengths {
+			if previous >= 0 && steps[kind] {
+				lengths[kind]++
+			} else {
+				lengths[kind] = 1
+			}
+			longest = max(longest, lengths[kind])
+		}
+		previous = char
+	}
+
+	return longest
+}
+
+// highest number of times any single char appears
+func mostOccurrences(password string) int {
+	counts := make(map[rune]int)
+	most := 0
+	for _, char := range password {
+		counts[char]++
+		most = max(most, counts[char])
+	}
+	return most
+}
+
+func isLetter(char rune) bool {
+	return classOf(char) == 0 || classOf(char) == 1
+}
+
+// how many passwords a policy can produce, per length
+// generation picks a length in proportion to these counts and then
+// rejects uniform draws, so the output is uniform over all of them
+// and log2 of the total is the exact entropy
+type policySpace struct {
+	minLength   int
+	log2Counts  []float64 // valid passwords of minLength+i chars
+	log2Total   float64
+	log2Draws   []float64 // uniform strings of minLength+i chars
+	alphabetLen int
+}
+
+// share of uniform draws that are accepted, over lengths up to maxLength
+func (ps policySpace) acceptance(maxLength int) float64 {
+	var valid, drawn []float64
+	for i := range ps.log2Counts {
+		if ps.minLength+i > maxLength {
+			break
+		}
+		valid = append(valid, ps.log2Counts[i])
+		drawn = append(drawn, ps.log2Draws[i])
+	}
+	return math.Exp2(log2Sum(valid) - log2Sum(drawn))
+}
+
+// length in proportion to its share of the valid passwords
+func (ps policySpace) drawLength(s *sampler) int {
+	weights := make([]float64, len(ps.log2Counts))
+	total := 0.0
+	for i, count := range ps.log2Counts {
+		weights[i] = math.Exp2(count - ps.log2Total)
+		total += weights[i]
+	}
+
+	target := s.Float64() * total
+	for i, weight := range weights {
+		if target < weight {
+			return ps.minLength + i
+		}
+		target -= weight
+	}
+
+	// rounding left a sliver past the end, fall back to the longest valid length
+	for i := len(weights) - 1; i > 0; i-- {
+		if weights[i] > 0 {
+			return ps.minLength + i
+		}
+	}
+	return ps.minLength
+}
+
+// log2 of the sum of 2^v for every v, without overflowing
+func log2Sum(values []float64) float64 {
+	peak := math.Inf(-1)
+	for _, value := range values {
+		peak = max(peak, value)
+	}
+	if math.IsInf(peak, -1) {
+		return peak
+	}
+
+	sum := 0.0
+	for _, value := range values {
+		sum += math.Exp2(value - peak)
+	}
+	return peak + math.Log2(sum)
+}
+
+// dynamic programming state while counting, fields a policy does not
+// constrain stay zero so equivalent prefixes merge
+type countState struct {
+	last     int16 // alphabet index of the previous char, -1 before the first
+	run      uint16
+	classRun uint16
+	walks    [stepKinds]uint16
+	have     [4]uint8 // chars seen per class, capped at the class minimum
+}
+
+// what a "plain" transition depends on: no repeat and no walk continues
+type countGroup struct {
+	lastClass int8 // classOf the previous char, -1 before the first
+	classRun  uint16
+	have      [4]uint8
+}
+
+// per-policy tables shared by every transition
+type spaceCounter struct {
+	policy     Policy
+	alphabet   []rune
+	classIndex []uint8 // policy class of every alphabet char
+	charClass  []int8  // classOf of every alphabet char
+	minimums   [4]uint8
+	needsLast  bool
+	special    [][]specialStep // per char, the next chars that may extend a run or walk
+}
+
+type specialStep struct {
+	next  int
+	steps [stepKinds]bool
+}
+
+func newSpaceCounter(p Policy) *spaceCounter {
+	counter := &spaceCounter{
+		policy:    p,
+		alphabet:  []rune(p.Alphabet()),
+		needsLast: p.MaxRepeat > 0 || p.MaxClassRepeat > 0 || p.MaxSequence > 0,
+	}
+
+	for i, class := range p.Classes() {
+		counter.minimums[i] = uint8(min(class.Min, math.MaxUint8))
+		for range class.Chars {
+			counter.classIndex = append(counter.classIndex, uint8(i))
+		}
+	}
+
+	counter.special = make([][]specialStep, len(counter.alphabet))
+	for i, previous := range counter.alphabet {
+		counter.charClass = append(counter.charClass, int8(classOf(previous)))
+
+		for j, next := range counter.alphabet {
+			steps := sequenceSteps(previous, next)
+			repeats := p.MaxRepeat > 0 && i == j
+			walks := p.MaxSequence > 0 && slices.Contains(steps[:], true)
+			if repeats || walks {
+				counter.special[i] = append(counter.special[i], specialStep{j, steps})
+			}
+		}
+	}
+
+	return counter
+}
+
+// target of char c after a prefix in group g when nothing continues
+func (sc *spaceCounter) plain(g countGroup, c int) (countState, bool) {
+	p := sc.policy
+	moved := countState{last: -1, have: g.have}
+	if sc.needsLast {
+		moved.last = int16(c)
+	}
+	if p.MaxRepeat > 0 {
+		moved.run = 1
+	}
+	if p.MaxSequence > 0 {
+		for kind := range moved.walks {
+			moved.walks[kind] = 1
+		}
+	}
+
+	if p.MaxClassRepeat > 0 {
+		moved.classRun = 1
+		if g.lastClass == sc.charClass[c] {
+			moved.classRun = g.classRun + 1
+		}
+		if int(moved.classRun) > p.MaxClassRepeat {
+			return moved, false
+		}
+	}
+
+	class := sc.classIndex[c]
+	moved.have[class] = min(moved.have[class]+1, sc.minimums[class])
+	return moved, true
+}
+
+// turns a plain transition into the real one for a special pair
+func (sc *spaceCounter) extend(state, moved countState, special specialStep) (countState, bool) {
+	p := sc.policy
+
+	if p.MaxRepeat > 0 && state.last == int16(special.next) {
+		moved.run = state.run + 1
+		if int(moved.run) > p.MaxRepeat {
+			return moved, false
+		}
+	}
+
+	if p.MaxSequence > 0 {
+		for kind := range moved.walks {
+			if special.steps[kind] {
+				moved.walks[kind] = state.walks[kind] + 1
+				if int(moved.walks[kind]) > p.MaxSequence {
+					return moved, false
+				}
+			}
+		}
+	}
+
+	return moved, true
+}
+
+func (sc *spaceCounter) group(state countState) countGroup {
+	g := countGroup{lastClass: -1, classRun: state.classRun, have: state.have}
+	if state.last >= 0 {
+		g.lastClass = sc.charClass[state.last]
+	}
+	return g
+}
+
+// one more char on every prefix: all transitions are first summed per
+// group as if plain, then the few special pairs are corrected
+func (sc *spaceCounter) advance(layer map[countState]float64, first bool) map[countState]float64 {
+	groups := make(map[countGroup]float64)
+	for state, count := range layer {
+		groups[sc.group(state)] += count
+	}
+
+	next := make(map[countState]float64, len(layer))
+	allowed := func(c int) bool {
+		return !first || !sc.policy.StartWithLetter || isLetter(sc.alphabet[c])
+	}
+
+	for g, count := range groups {
+		for c := range sc.alphabet {
+			if !allowed(c) {
+				continue
+			}
+			if moved, ok := sc.plain(g, c); ok {
+				next[moved] += count
+			}
+		}
+	}
+
+	for state, count := range layer {
+		if state.last < 0 {
+			continue
+		}
+		g := sc.group(state)
+		for _, special := range sc.special[state.last] {
+			moved, ok := sc.plain(g, special.next)
+			if !ok {
+				continue
+			}
+			next[moved] -= count
+			if moved, ok := sc.extend(state, moved, special); ok {
+				next[moved] += count
+			}
+		}
+	}
+
+	// cancellation can leave dust where a state lost all its prefixes
+	for state, count := range next {
+		if count <= 0 {
+			delete(next, state)
+		}
+	}
+
+	return next
+}
+
+// counting a constrained policy takes a moment and Validate, SetPolicy and
+// the paranoia switch all ask for the same few policies
+var spaceCache = struct {
+	sync.Mutex
+	spaces map[Policy]policySpace
+}{spaces: make(map[Policy]policySpace)}
+
+const spaceCacheSize = 32
+
+func (p Policy) space() policySpace {
+	spaceCache.Lock()
+	space, ok := spaceCache.spaces[p]
+	spaceCache.Unlock()
+	if ok {
+		return space
+	}
+
+	space = p.countSpace()
+
+	spaceCache.Lock()
+	if len(spaceCache.spaces) >= spaceCacheSize {
+		clear(spaceCache.spaces)
+	}
+	spaceCache.spaces[p] = space
+	spaceCache.Unlock()
+	return space
+}
+
+// exact counts for every length in the policy's range
+func (p Policy) countSpace() policySpace {
+	counter := newSpaceCounter(p)
+	alphabetSize := len(counter.alphabet)
+
+	space := policySpace{minLength: p.MinLength, alphabetLen: alphabetSize}
+	if alphabetSize == 0 || p.MaxLength < p.MinLength || p.uncountable() {
+		space.log2Total = math.Inf(-1)
+		return space
+	}
+
+	if p.limitsOccurrences() {
+		p.countOccurrences(&space)
+		space.log2Total = log2Sum(space.log2Counts)
+		return space
+	}
+
+	layer := map[countState]float64{{last: -1}: 1}
+	scale := 0.0 // log2 factor divided out of the layer so far
+
+	for length := 1; length <= p.MaxLength; length++ {
+		layer = counter.advance(layer, length == 1)
+
+		// keep the numbers in float range
+		peak := 0.0
+		for _, count := range layer {
+			peak = max(peak, count)
+		}
+		if peak > math.Exp2(512) {
+			for state := range layer {
+				layer[state] /= math.Exp2(512)
+			}
+			scale += 512
+		}
+
+		if length < p.MinLength {
+			continue
+		}
+
+		valid := 0.0
+		for state, count := range layer {
+			if state.have == counter.minimums {
+				valid += count
+			}
+		}
+		space.log2Counts = append(space.log2Counts, math.Log2(valid)+scale)
+		space.log2Draws = append(space.log2Draws, float64(length)*math.Log2(float64(alphabetSize)))
+	}
+
+	space.log2Total = log2Sum(space.log2Counts)
+	return space
+}
+
+// a limit of at least the maximum length never rejects anything
+func (p Policy) limitsOccurrences() bool {
+	return p.MaxOccurrences > 0 && p.MaxOccurrences < p.MaxLength
+}
+
+// occurrence limits need a count per char, too many states to track next
+// to runs and walks, so the two kinds of rule are not combined
+func (p Policy) uncountable() bool {
+	return p.limitsOccurrences() && (p.MaxRepeat > 0 || p.MaxClassRepeat > 0 || p.MaxSequence > 0)
+}
+
+// with only an occurrence limit the order of the chars is free, so the
+// strings of a class are its single-char strings of up to MaxOccurrences
+// copies interleaved in every way, and the classes interleave the same way
+// a letter first is counted by fixing that letter and interleaving the rest
+func (p Policy) countOccurrences(space *policySpace) {
+	oc := newOccurrenceCounter(p.MaxLength)
+	classes := p.Classes()
+
+	merged := func(skip int, first []float64) []float64 {
+		counts := first
+		for i, class := range classes {
+			if i != skip {
+				counts = oc.merge(counts, oc.class(len(class.Chars), class.Min, p.MaxOccurrences))
+			}
+		}
+		return counts
+	}
+
+	counts := merged(-1, oc.empty())
+	if p.StartWithLetter {
+		counts = oc.none()
+		for i, class := range classes {
+			letters := 0
+			for _, char := range class.Chars {
+				if isLetter(char) {
+					letters++
+				}
+			}
+			if letters == 0 {
+				continue
+			}
+
+			rest := oc.merge(oc.class(len(class.Chars)-1, 0, p.MaxOccurrences), oc.single(p.MaxOccurrences-1))
+			oc.atLeast(rest, class.Min-1)
+			rest = merged(i, rest)
+			for length := 1; length < len(counts); length++ {
+				counts[length] = log2Sum([]float64{counts[length], math.Log2(float64(letters)) + rest[length-1]})
+			}
+		}
+	}
+
+	for length := p.MinLength; length <= p.MaxLength; length++ {
+		space.log2Counts = append(space.log2Counts, counts[length])
+		space.log2Draws = append(space.log2Draws, float64(length)*math.Log2(float64(space.alphabetLen)))
+	}
+}
+
+// log2 counts of strings per length, 0 to maxLength, -Inf when there are none
+type occurrenceCounter struct {
+	maxLength      int
+	log2Factorials []float64
+}
+
+func newOccurrenceCounter(maxLength int) occurrenceCounter {
+	oc := occurrenceCounter{maxLength: maxLength, log2Factorials: make([]float64, maxLength+1)}
+	for n := 1; n <= maxLength; n++ {
+		oc.log2Factorials[n] = oc.log2Factorials[n-1] + math.Log2(float64(n))
+	}
+	return oc
+}
+
+// no strings at all
+func (oc occurrenceCounter) none() []float64 {
+	counts := make([]float64, oc.maxLength+1)
+	for n := range counts {
+		counts[n] = math.Inf(-1)
+	}
+	return counts
+}
+
+// only the empty string
+func (oc occurrenceCounter) empty() []float64 {
+	counts := oc.none()
+	counts[0] = 0
+	return counts
+}
+
+// one char repeated up to limit times
+func (oc occurrenceCounter) single(limit int) []float64 {
+	counts := oc.none()
+	for n := 0; n <= min(limit, oc.maxLength); n++ {
+		counts[n] = 0
+	}
+	return counts
+}
+
+// chars distinct chars each used up to limit times, at least minimum in all
+func (oc occurrenceCounter) class(chars, minimum, limit int) []float64 {
+	counts, power := oc.empty(), oc.single(limit)
+	for ; chars > 0; chars >>= 1 {
+		if chars&1 == 1 {
+			counts = oc.merge(counts, power)
+		}
+		if chars > 1 {
+			power = oc.merge(power, power)
+		}
+	}
+	oc.atLeast(counts, minimum)
+	return counts
+}
+
+func (oc occurrenceCounter) atLeast(counts []float64, minimum int) {
+	for n := range min(max(minimum, 0), len(counts)) {
+		counts[n] = math.Inf(-1)
+	}
+}
+
+// every interleaving of a string from a with one from b, over disjoint
+// alphabets: C(n, t) ways to pick the positions of a's t chars
+func (oc occurrenceCounter) merge(a, b []float64) []float64 {
+	topA, topB := longest(a), longest(b)
+	merged := oc.none()
+	terms := make([]float64, 0, len(merged))
+
+	for n := range min(topA+topB, oc.maxLength) + 1 {
+		terms = terms[:0]
+		for t := max(0, n-topB); t <= min(n, topA); t++ {
+			if math.IsInf(a[t], -1) || math.IsInf(b[n-t], -1) {
+				continue
+			}
+			binomial := oc.log2Factorials[n] - oc.log2Factorials[t] - oc.log2Factorials[n-t]
+			terms = append(terms, a[t]+b[n-t]+binomial)
+		}
+		merged[n] = log2Sum(terms)
+	}
+	return merged
+}
+
+// highest length with any strings, -1 when there are none
+func longest(counts []float64) int {
+	for n := len(counts) - 1; n >= 0; n-- {
+		if !math.IsInf(counts[n], -1) {
+			return n
+		}
+	}
+	return -1
+}
+
+func drawUniform(s *sampler, alphabet string, length int) string {
+	var builder strings.Builder
+	builder.Grow(length)
+	for range length {
+		builder.WriteByte(alphabet[s.Intn(len(alphabet))])
+	}
+	return builder.String()
+}
@@ -0,0 +1,91 @@
+package password
+
+import (
+	"fmt"
+	"math"
+)
+
+// what renting the attack costs, per GPU
+type PriceTable struct {
+	GPUHour  float64 // dollars per GPU-hour of cloud rental
+	GPUWatts float64 // power draw per GPU
+}
+
+// an RTX 4090 on a GPU rental marketplace, a rough figure, set your own in
+// the [prices] section of attack-models.conf
+func DefaultPrices() PriceTable {
+	return PriceTable{GPUHour: 0.40, GPUWatts: 450}
+}
+
+// the economic side of a crack time
+type AttackCost struct {
+	Dollars         float64
+	KWh             float64
+	HashesPerDollar float64
+}
+
+// cost of running the model's rig for seconds, the expected time to a 50%
+// chance as CrackSecondsForModel and CrackSecondsForEntropy report it
+// models without GPUs (online, quantum) have no cost
+func (m AttackModel) Cost(seconds float64) (AttackCost, bool) {
+	if m.GPUs == 0 || m.Quantum != nil || m.Price.GPUHour <= 0 {
+		return AttackCost{}, false
+	}
+
+	gpuHours := seconds / 3600 * float64(m.GPUs)
+	return AttackCost{
+		Dollars:         gpuHours * m.Price.GPUHour,
+		KWh:             gpuHours * m.Price.GPUWatts / 1000,
+		HashesPerDollar: m.GuessesPerSec * 3600 / (float64(m.GPUs) * m.Price.GPUHour),
+	}, true
+}
+
+// e.g. "$12.50", "$4.2 million", "$3.6e+49"
+func FormatDollars(dollars float64) string {
+	switch {
+	case math.IsInf(dollars, 0) || math.IsNaN(dollars):
+		return "more money than exists"
+	case dollars < 0.01:
+		return "< $0.01"
+	case dollars < 1000:
+		return fmt.Sprintf("$%.2f", dollars)
+	case dollars >= 1e15:
+		return fmt.Sprintf("$%.2g", dollars)
+	}
+
+	for _, scale := range []struct {
+		name  string
+		value float64
+	}{{"trillion", 1e12}, {"billion", 1e9}, {"million", 1e6}, {"thousand", 1e3}} {
+		if dollars >= scale.value {
+			return fmt.Sprintf("$%.3g %s", dollars/scale.value, scale.name)
+		}
+	}
+	return fmt.Sprintf("$%.0f", dollars)
+}
+
+// world electricity consumption in a year, about 30,000 TWh
+const worldYearlyKWh = 3e13
+
+// e.g. "12 kWh", "4.2 GWh", "3.1× world electricity/yr"
+func FormatEnergy(kWh float64) string {
+	switch {
+	case math.IsInf(kWh, 0) || math.IsNaN(kWh):
+		return "more energy than exists"
+	case kWh >= worldYearlyKWh:
+		return fmt.Sprintf("%.2g× world electricity/yr", kWh/worldYearlyKWh)
+	}
+
+	units := []string{"kWh", "MWh", "GWh", "TWh"}
+	unit := 0
+	for kWh >= 1000 && unit < len(units)-1 {
+		kWh /= 1000
+		unit++
+	}
+	return fmt.Sprintf("%.3g %s", kWh, units[unit])
+}
+
+// e.g. "5.2e+12 guesses/$"
+func FormatHashesPerDollar(hashes float64) string {
+	return fmt.Sprintf("%.3g guesses/$", hashes)
+}
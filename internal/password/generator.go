@@ -1,324 +1,542 @@
 package password
 
 import (
-	"encoding/binary"
 	"fmt"
 	"math"
 	"math/rand"
 	"sync"
 
 	"datflux/internal/entropy"
+)
+
+// what Generate produces
+type Mode int
 
-	"github.com/nbutton23/zxcvbn-go"
+const (
+	ModeCharacters    Mode = iota // random characters from the policy alphabet
+	ModePassphrase                // diceware words from a wordlist
+	ModePronounceable             // syllables from a phonotactic model
+	ModeTemplate                  // positions dictated by a pattern
+	ModeRegex                     // uniform over a bounded regex's matches
 )
 
+func (m Mode) String() string {
+	switch m {
+	case ModePassphrase:
+		return "Passphrase"
+	case ModePronounceable:
+		return "Pronounceable"
+	case ModeTemplate:
+		return "Template"
+	case ModeRegex:
+		return "Regex"
+	default:
+		return "Characters"
+	}
+}
+
 type Generator struct {
-	collector       *entropy.Collector
-	minLength       int
-	maxLength       int
-	useSymbols      bool
-	useNumbers      bool
-	useUpper        bool
-	useLower        bool
-	paranoiaMode    bool
-	paranoiaSamples int
+	collector     *entropy.Collector
+	mode          Mode
+	policy        Policy
+	passphrase    PassphraseOptions
+	wordlist      *Wordlist
+	pronounceable PronounceableOptions
+	template      *Template
+	regex         *Regex
+	profile       string   // name of the applied profile, "" for none
+	userInputs    []string // the applied profile's words for zxcvbn
+	paranoiaMode  bool
+	paranoia      ParanoiaOptions
+	space         policySpace // counts for activePolicy, refreshed on change
+	blacklist     *Blacklist
+	blacklisted   blacklistCounters
+	settings      uint64          // bumped on every change, see SettingsVersion
+	generated     generatedResult // the last password Generate returned
 }
 
-type PasswordStrength struct {
-	Score            int     // 0-4 (0=very weak, 4=very strong)
-	EntropyBits      float64 // estimated entropy bits
-	CrackTimeDesc    string  // human-readable crack time estimate
-	CrackTimeSeconds float64 // estimated crack time in s
-	Feedback         string  // feedback to improve the password
+// a password with the exact entropy of the settings that made it
+type generatedResult struct {
+	password string
+	exact    ExactResult
 }
 
-type AttackModelType int
+// what the analyzers found, see Analyzer
+type PasswordStrength struct {
+	Scored           bool        // zxcvbn filled the score, crack time and user match fields
+	Score            int         // 0-4 (0=very weak, 4=very strong)
+	EntropyBits      float64     // estimated entropy bits
+	CrackTimeDesc    string      // human-readable crack time estimate
+	CrackTimeSeconds float64     // estimated crack time in s
+	Exact            ExactResult // entropy of the settings that made it, if known
+	UserMatches      []string    // user inputs found in the password
+	UserPenalty      float64     // bits those matches cost
+	Blacklisted      string      // the blacklisted word found, "" if none
+	BreachChecked    bool        // a breach index was searched
+	BreachCount      int         // times it appears there, 0 if absent
+	Violations       []string    // policy, pwquality or breach rules it breaks
+	Feedback         string      // feedback to improve the password
+}
 
-const (
-	OnlineRateLimited  AttackModelType = iota // default zxcvbn (100 guesses/sec)
-	OfflineGPUCracking                        // serious offline attack (1 billion guesses/sec)
-	QuantumComputing                          // theoretical future attack (10^15+ guesses/sec)
-)
+func NewGenerator(collector *entropy.Collector) *Generator {
+	generator := &Generator{
+		collector:     collector,
+		mode:          ModeCharacters,
+		policy:        DefaultPolicy(),
+		passphrase:    DefaultPassphraseOptions(),
+		wordlist:      EFFLargeWordlist(),
+		pronounceable: DefaultPronounceableOptions(),
+		paranoiaMode:  false,
+		paranoia:      DefaultParanoiaOptions(),
+	}
+	generator.settingsChanged()
+	return generator
+}
 
-type AttackModel struct {
-	Name          string
-	Description   string
-	GuessesPerSec float64
-}
-
-func GetAttackModels() []AttackModel {
-	return []AttackModel{
-		{
-			Name:          "Online Rate-Limited",
-			Description:   "Standard online attack with rate limiting (100 guesses/sec)",
-			GuessesPerSec: 100, // 10ms per guess
-		},
-		{
-			Name:          "Offline GPU Cracking",
-			Description:   "Serious password file breach (1 billion guesses/sec)",
-			GuessesPerSec: 1e9, // 1 billion
-		},
-		{
-			Name:          "Quantum Computing",
-			Description:   "State-level adversary with advanced tech (10^15 guesses/sec)",
-			GuessesPerSec: 1e15, // 1 quadrillion
-		},
+// the policy character passwords are actually drawn from
+// paranoia mode ignores the policy's length unless a profile dictates it
+func (g *Generator) activePolicy() Policy {
+	if g.paranoiaMode {
+		return g.paranoiaPolicy(g.policy, g.paranoia)
 	}
+	return g.policy
 }
 
-func NewGenerator(collector *entropy.Collector) *Generator {
-	return &Generator{
-		collector:       collector,
-		minLength:       16,
-		maxLength:       32,
-		useSymbols:      true,
-		useNumbers:      true,
-		useUpper:        true,
-		useLower:        true,
-		paranoiaMode:    false,
-		paranoiaSamples: 25,
+// paranoia mode draws its own lengths, unless a profile dictates them
+func (g *Generator) paranoiaPolicy(policy Policy, opts ParanoiaOptions) Policy {
+	if g.profile == "" {
+		policy.MinLength, policy.MaxLength = opts.MinLength, opts.MaxLength
 	}
+	return policy
 }
 
-func (g *Generator) SetParanoiaMode(enabled bool, samples int) {
-	g.paranoiaMode = enabled
-	g.paranoiaSamples = max(1, samples)
+// every setter ends here, cached analyses of older settings go stale
+func (g *Generator) settingsChanged() {
+	g.space = g.activePolicy().space()
+	g.settings++
 }
 
-func (g *Generator) GetParanoiaMode() (bool, int) {
-	return g.paranoiaMode, g.paranoiaSamples
+// changes whenever a setting does, key cached analyses on it
+func (g *Generator) SettingsVersion() uint64 {
+	return g.settings
 }
 
-func (g *Generator) Generate() string {
+// replaces the policy, invalid ones leave the current policy in place
+func (g *Generator) SetPolicy(policy Policy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
 	if g.paranoiaMode {
-		return g.generateParanoid()
+		if err := g.paranoiaPolicy(policy, g.paranoia).Validate(); err != nil {
+			return fmt.Errorf("paranoia mode: %w", err)
+		}
 	}
 
-	// standard
-	seed := g.collector.GenerateSeed()
-	return g.generateWithSeed(seed)
+	g.policy = policy
+	g.settingsChanged()
+	return nil
 }
 
-// out of 25 passwords, returns the one with highest entropy
-func (g *Generator) generateParanoid() string {
-	// pre-allocate candidates and corresponding entropy values
-	candidates := make([]string, g.paranoiaSamples)
-	entropies := make([]float64, g.paranoiaSamples)
-
-	// goroutines to get candidates in parallel
-	var wg sync.WaitGroup
-	wg.Add(g.paranoiaSamples)
+func (g *Generator) GetPolicy() Policy {
+	return g.policy
+}
 
-	for i := range make([]struct{}, g.paranoiaSamples) {
-		go func(index int) {
-			defer wg.Done()
+func (g *Generator) SetMode(mode Mode) {
+	g.mode = mode
+	g.settingsChanged()
+}
 
-			// 512-bit entropy for each candidate password
-			entropyBytes := g.collector.GetRawEntropy512()
-			// first 8 bytes for seed
-			seed := int64(binary.LittleEndian.Uint64(entropyBytes[:8]))
-			candidates[index] = g.generateWithSeed(seed)
+func (g *Generator) GetMode() Mode {
+	return g.mode
+}
 
-			// analyze strength
-			strength := g.AnalyzeStrength(candidates[index])
-			entropies[index] = strength.EntropyBits
-		}(i)
+func (g *Generator) SetPassphraseOptions(opts PassphraseOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
 	}
 
-	wg.Wait()
+	g.passphrase = opts
+	g.settingsChanged()
+	return nil
+}
 
-	// keep the one with highest entropy
-	bestIndex := 0
-	for i, entropy := range entropies {
-		if entropy > entropies[bestIndex] {
-			bestIndex = i
-		}
+func (g *Generator) GetPassphraseOptions() PassphraseOptions {
+	return g.passphrase
+}
+
+func (g *Generator) SetWordlist(wordlist *Wordlist) {
+	g.wordlist = wordlist
+	g.settingsChanged()
+}
+
+func (g *Generator) GetWordlist() *Wordlist {
+	return g.wordlist
+}
+
+func (g *Generator) SetPronounceableOptions(opts PronounceableOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
 	}
 
-	return candidates[bestIndex]
+	g.pronounceable = opts
+	g.settingsChanged()
+	return nil
 }
 
-// uses the given seed to generate a password
-func (g *Generator) generateWithSeed(seed int64) string {
-	// nosec G404 -- uses a securely seeded PRNG
-	source := rand.NewSource(seed)
-	secureRand := rand.New(source)
+func (g *Generator) GetPronounceableOptions() PronounceableOptions {
+	return g.pronounceable
+}
 
-	var lowercase = "abcdefghijklmnopqrstuvwxyz"
-	var uppercase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	var numbers = "0123456789"
-	var symbols = "!@#$%^&*()_+-=[]{}|;:,.<>?/"
+func (g *Generator) SetTemplate(template *Template) {
+	g.template = template
+	g.settingsChanged()
+}
 
-	var allChars string
-	var requiredChars []byte
+func (g *Generator) GetTemplate() *Template {
+	return g.template
+}
 
-	if g.useLower {
-		allChars += lowercase
-		// nosec G404 -- uses a securely seeded PRNG
-		requiredChars = append(requiredChars, lowercase[secureRand.Intn(len(lowercase))])
-	}
+func (g *Generator) SetRegex(regex *Regex) {
+	g.regex = regex
+	g.settingsChanged()
+}
 
-	if g.useUpper {
-		allChars += uppercase
-		requiredChars = append(requiredChars, uppercase[secureRand.Intn(len(uppercase))])
-	}
+func (g *Generator) GetRegex() *Regex {
+	return g.regex
+}
 
-	if g.useNumbers {
-		allChars += numbers
-		requiredChars = append(requiredChars, numbers[secureRand.Intn(len(numbers))])
+// switches to the profile's template or policy and remembers its name
+func (g *Generator) ApplyProfile(profile Profile) error {
+	if err := profile.Validate(); err != nil {
+		return err
 	}
 
-	if g.useSymbols {
-		allChars += symbols
-		requiredChars = append(requiredChars, symbols[secureRand.Intn(len(symbols))])
+	if profile.Mode() == ModeTemplate {
+		template, err := ParseTemplate(profile.Pattern)
+		if err != nil {
+			return err
+		}
+		g.template = template
+	} else {
+		g.policy = profile.Policy
 	}
 
-	// longer passwords in paranoia mode
-	passLength := g.minLength + secureRand.Intn(g.maxLength-g.minLength+1)
+	g.mode = profile.Mode()
+	g.profile = profile.Name
+	g.userInputs = profile.UserInputs
+	g.settingsChanged()
+	return nil
+}
+
+func (g *Generator) GetProfile() string {
+	return g.profile
+}
+
+// forgets the profile name, the settings it applied stay
+func (g *Generator) ClearProfile() {
+	g.profile = ""
+	g.userInputs = nil
+	g.settingsChanged()
+}
+
+// forgets the profile and goes back to policy, the one from before it
+// fails and keeps the profile when the generator can't use policy, in
+// paranoia mode with the paranoia lengths, which apply again without it
+func (g *Generator) LeaveProfile(policy Policy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
 	if g.paranoiaMode {
-		// 48-80 chars in paranoia mode
-		passLength = 48 + secureRand.Intn(33)
+		lengths := policy
+		lengths.MinLength, lengths.MaxLength = g.paranoia.MinLength, g.paranoia.MaxLength
+		if err := lengths.Validate(); err != nil {
+			return fmt.Errorf("paranoia mode: %w", err)
+		}
 	}
-	passLength = max(passLength, len(requiredChars))
 
-	password := make([]byte, passLength)
+	g.policy = policy
+	g.profile = ""
+	g.userInputs = nil
+	g.settingsChanged()
+	return nil
+}
+
+// nil turns the blacklist off, the counts restart either way
+func (g *Generator) SetBlacklist(blacklist *Blacklist) {
+	g.blacklist = blacklist
+	g.blacklisted.drawn.Store(0)
+	g.blacklisted.rejected.Store(0)
+	g.settingsChanged()
+}
 
-	for i, char := range requiredChars {
-		password[i] = char
+func (g *Generator) GetBlacklist() *Blacklist {
+	return g.blacklist
+}
+
+func (g *Generator) GetBlacklistStats() BlacklistStats {
+	return BlacklistStats{Drawn: g.blacklisted.drawn.Load(), Rejected: g.blacklisted.rejected.Load()}
+}
+
+// words from the applied profile, see ZxcvbnAnalyzer.Context
+func (g *Generator) GetUserInputs() []string {
+	return g.userInputs
+}
+
+// exact entropy of the current generation process, for the modes
+// where zxcvbn's estimate is meaningless (passphrases, pronounceable,
+// templates, regexes)
+func (g *Generator) ExactEntropy() (float64, bool) {
+	switch g.mode {
+	case ModePassphrase:
+		return g.passphrase.Entropy(g.wordlist), true
+	case ModePronounceable:
+		return g.pronounceable.Entropy(), true
+	case ModeTemplate:
+		if g.template == nil {
+			return 0, false
+		}
+		return g.template.Entropy(), true
+	case ModeRegex:
+		if g.regex == nil {
+			return 0, false
+		}
+		return g.regex.Entropy(), true
+	default:
+		// log2 of every password the policy accepts, each equally likely,
+		// minus what paranoia's candidate selection can cost
+		bits := g.space.log2Total
+		if g.paranoiaMode {
+			selectionCost, _ := g.paranoia.SelectionCost()
+			bits -= selectionCost
+		}
+		return bits, !math.IsInf(bits, -1)
 	}
+}
+
+// exact entropy of the settings a password came from, captured when it is
+// generated so later setting changes don't relabel it
+type ExactResult struct {
+	Bits  float64
+	Known bool
+}
+
+func (g *Generator) CaptureExact() ExactResult {
+	bits, known := g.ExactEntropy()
+	return ExactResult{Bits: bits, Known: known}
+}
 
-	for i := len(requiredChars); i < passLength; i++ {
-		password[i] = allChars[secureRand.Intn(len(allChars))]
+// gaps below this are noise, zxcvbn rounds and simplifies a lot
+const (
+	divergenceMinBits  = 16
+	divergenceMinShare = 0.15
+)
+
+// zxcvbn's estimate minus the exact figure, and whether the gap is large
+// enough to mislead, positive means zxcvbn overrates the password
+func (r ExactResult) Divergence(estimate float64) (float64, bool) {
+	if !r.Known || r.Bits <= 0 {
+		return 0, false
 	}
 
-	// Fisher-Yates shuffle
-	// multiple passes in paranoia mode
-	numPasses := 1
-	if g.paranoiaMode {
-		numPasses = 3 // more thorough shuffling in paranoia mode
+	gap := estimate - r.Bits
+	return gap, math.Abs(gap) >= divergenceMinBits && math.Abs(gap)/r.Bits >= divergenceMinShare
+}
+
+// enabling fails when the policy has no valid password in the paranoia
+// length range, e.g. hex with a low --max-occurrences
+func (g *Generator) SetParanoiaMode(enabled bool, samples int) error {
+	if enabled {
+		if err := g.paranoiaPolicy(g.policy, g.paranoia).Validate(); err != nil {
+			return fmt.Errorf("paranoia mode: %w", err)
+		}
 	}
 
-	for range make([]struct{}, numPasses) {
-		for i := range password {
-			j := secureRand.Intn(i + 1)
-			password[i], password[j] = password[j], password[i]
+	g.paranoiaMode = enabled
+	g.paranoia.Candidates = min(max(1, samples), maxParanoiaCandidates)
+	g.settingsChanged()
+	return nil
+}
+
+func (g *Generator) GetParanoiaMode() (bool, int) {
+	return g.paranoiaMode, g.paranoia.Candidates
+}
+
+func (g *Generator) SetParanoiaOptions(opts ParanoiaOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if g.paranoiaMode {
+		if err := g.paranoiaPolicy(g.policy, opts).Validate(); err != nil {
+			return fmt.Errorf("paranoia mode: %w", err)
 		}
 	}
 
-	return string(password)
+	g.paranoia = opts
+	g.settingsChanged()
+	return nil
 }
 
-func (g *Generator) GenerateRandomChar() byte {
-	var allChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!@#$%^&*()_+-="
-	// nosec G404 -- only used for visual animation
-	return allChars[rand.Intn(len(allChars))]
+func (g *Generator) GetParanoiaOptions() ParanoiaOptions {
+	return g.paranoia
 }
 
-// uses zxcvbn to evaluate password strength
-func (g *Generator) AnalyzeStrength(password string) PasswordStrength {
-	result := zxcvbn.PasswordStrength(password, nil)
-
-	// feedback based on score
-	feedback := ""
-	if result.Score < 3 {
-		if len(password) < 12 {
-			feedback = "Consider a longer password"
-		} else {
-			feedback = "Try adding more varied characters"
+// fails rather than return a password the policy or the blacklist rejects
+// the exact entropy of the settings is kept with the password, see ExactAnalyzer
+func (g *Generator) Generate() (string, error) {
+	password, err := g.generateAllowed()
+	if err != nil {
+		return "", err
+	}
+	g.generated = generatedResult{password, g.CaptureExact()}
+	return password, nil
+}
+
+// passwords containing a blacklisted word are redrawn, except passphrases,
+// which are dictionary words by design
+func (g *Generator) generateAllowed() (string, error) {
+	if g.blacklist == nil || g.mode == ModePassphrase {
+		return g.generateOnce()
+	}
+
+	for range maxBlacklistAttempts {
+		password, err := g.generateOnce()
+		if err != nil {
+			return "", err
+		}
+		g.blacklisted.drawn.Add(1)
+		if _, banned := g.blacklist.Match(password); !banned {
+			return password, nil
 		}
+		g.blacklisted.rejected.Add(1)
 	}
+	return "", fmt.Errorf("all %d draws contained a blacklisted word, loosen the policy or the blacklist", maxBlacklistAttempts)
+}
 
-	return PasswordStrength{
-		Score:            result.Score,
-		EntropyBits:      float64(result.Entropy),
-		CrackTimeDesc:    result.CrackTimeDisplay,
-		CrackTimeSeconds: result.CrackTime,
-		Feedback:         feedback,
+func (g *Generator) generateOnce() (string, error) {
+	// paranoia mode only applies to character passwords
+	switch g.mode {
+	case ModePassphrase:
+		return generatePassphrase(newSampler(g.collector), g.wordlist, g.passphrase), nil
+	case ModePronounceable:
+		return generatePronounceable(newSampler(g.collector), g.pronounceable), nil
+	case ModeTemplate:
+		if g.template != nil {
+			return generateFromTemplate(newSampler(g.collector), g.template), nil
+		}
+	case ModeRegex:
+		if g.regex != nil {
+			return generateFromRegex(newSampler(g.collector), g.regex), nil
+		}
 	}
+
+	if g.paranoiaMode {
+		return g.generateParanoid()
+	}
+
+	// standard
+	return g.generateFrom(newSampler(g.collector))
 }
 
-// crack time adjusted for a specific attack model
-func (g *Generator) GetAdjustedCrackTime(baseSeconds float64, modelType AttackModelType) float64 {
-	models := GetAttackModels()
+// out of the configured candidates, returns the one with highest entropy
+// the selection skews the output, ParanoiaOptions.SelectionCost says by how much
+func (g *Generator) generateParanoid() (string, error) {
+	samples := g.paranoia.Candidates
+
+	// pre-allocate candidates and corresponding entropy values
+	candidates := make([]string, samples)
+	entropies := make([]float64, samples)
+	errs := make([]error, samples)
+
+	// goroutines to get candidates in parallel
+	var wg sync.WaitGroup
+	wg.Add(samples)
+
+	for i := range make([]struct{}, samples) {
+		go func(index int) {
+			defer wg.Done()
+
+			// 512-bit entropy for each candidate password
+			// the whole block drives the sampler, refilled as it runs dry
+			entropyBytes := g.collector.GetRawEntropy512()
+			candidates[index], errs[index] = g.generateFrom(newParanoidSampler(g.collector, entropyBytes))
+			if samples == 1 || errs[index] != nil {
+				return
+			}
 
-	// zxcvbn's default is 10ms/guess
-	defaultGuessesPerSec := 100.0
-	modelGuessesPerSec := models[modelType].GuessesPerSec
+			// analyze strength, uncached, candidates are never seen again
+			strength := ZxcvbnAnalyzer{}.Analyze(candidates[index])
+			entropies[index] = strength.EntropyBits
+		}(i)
+	}
+
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// keep the one with highest entropy
+	bestIndex := 0
+	for i, entropy := range entropies {
+		if entropy > entropies[bestIndex] {
+			bestIndex = i
+		}
+	}
 
-	adjustmentFactor := modelGuessesPerSec / defaultGuessesPerSec
-	return baseSeconds / adjustmentFactor
+	return candidates[bestIndex], nil
 }
 
-func (g *Generator) GetCrackTimeForModel(password string, modelType AttackModelType) string {
-	result := zxcvbn.PasswordStrength(password, nil)
-
-	// for quantum computing, use entropy directly
-	if modelType == QuantumComputing {
-		entropyBits := float64(result.Entropy)
-		quantumEntropyBits := entropyBits / 2
-		quantumCrackTime := math.Pow(2, quantumEntropyBits) / GetAttackModels()[QuantumComputing].GuessesPerSec
-		return GetCrackTimeDescription(quantumCrackTime)
+// the length is drawn in proportion to how many valid passwords it has,
+// then uniform draws are rejected until one passes every rule, so each
+// valid password is equally likely
+func (g *Generator) generateFrom(s *sampler) (string, error) {
+	policy := g.activePolicy()
+	if math.IsInf(g.space.log2Total, -1) {
+		return "", fmt.Errorf("%w: no password of %s satisfies every rule", ErrImpossiblePolicy, policy.lengthRange())
 	}
+	alphabet := policy.Alphabet()
+	length := g.space.drawLength(s)
 
-	adjustedTime := g.GetAdjustedCrackTime(result.CrackTime, modelType)
-
-	return GetCrackTimeDescription(adjustedTime)
-}
-
-// descriptions based on crack time
-func GetCrackTimeDescription(seconds float64) string {
-	// time constants
-	minute := float64(60)
-	hour := minute * 60
-	day := hour * 24
-	month := day * 30
-	year := day * 365
-	decade := year * 10
-	century := year * 100
-
-	universeAge := 13.8 * 1e9 * year
-
-	switch {
-	case seconds < 0.001:
-		return "instant"
-	case seconds < 1:
-		return "< 1 second"
-	case seconds < minute:
-		return fmt.Sprintf("%d seconds", int(seconds))
-	case seconds < hour:
-		return fmt.Sprintf("%d minutes", int(seconds/minute))
-	case seconds < day:
-		return fmt.Sprintf("%d hours", int(seconds/hour))
-	case seconds < 7*day:
-		return fmt.Sprintf("%d days", int(seconds/day))
-	case seconds < month:
-		return fmt.Sprintf("%d weeks", int(seconds/(7*day)))
-	case seconds < year:
-		return fmt.Sprintf("%d months", int(seconds/month))
-	case seconds < decade:
-		return fmt.Sprintf("%d years", int(seconds/year))
-	case seconds < century:
-		return fmt.Sprintf("%d decades", int(seconds/decade))
-	case seconds < 10*century:
-		return fmt.Sprintf("%d centuries", int(seconds/century))
-	case seconds < universeAge:
-		billionYears := seconds / year / 1e9
-
-		if billionYears < 0.1 {
-			millennia := int(seconds / (10 * century))
-			return fmt.Sprintf("%d millennia", millennia)
+	password := g.drawCandidate(s, alphabet, length)
+	for attempt := 1; !policy.Accepts(password); attempt++ {
+		if attempt == maxRejections {
+			return "", fmt.Errorf("%w: no draw of %d passed the rules", ErrImpossiblePolicy, maxRejections)
 		}
+		password = g.drawCandidate(s, alphabet, length)
+	}
+	return password, nil
+}
 
-		return fmt.Sprintf("%.1f billion years", billionYears)
-	default:
-		cosmicScale := seconds / universeAge
-		if cosmicScale < 1000 {
-			// at least 1.1x, to avoid strange values
-			if cosmicScale < 1.1 {
-				return "the age of the universe"
-			}
-			return fmt.Sprintf("%.1f× the age of the universe", cosmicScale)
+// draws every character and every shuffle swap from the sampler
+func (g *Generator) drawCandidate(s *sampler, alphabet string, length int) string {
+	password := []byte(drawUniform(s, alphabet, length))
+
+	// a uniform string stays uniform under shuffling, the extra
+	// passes in paranoia mode only burn more of the entropy stream
+	if g.paranoiaMode {
+		for range make([]struct{}, g.paranoia.ShufflePasses) {
+			s.Shuffle(password)
 		}
-		return "until the heat death of the universe"
 	}
+
+	return string(password)
+}
+
+// zxcvbn with the profile's user inputs and the exact entropy of the
+// current settings, uncached; the TUI and check build their own Analyzer
+func (g *Generator) AnalyzeStrength(password string) PasswordStrength {
+	return Combine(ZxcvbnAnalyzer{Context: g.GetUserInputs}, NewExactAnalyzer(g)).Analyze(password)
+}
+
+// crack time against one of the built-in attack models
+func (g *Generator) GetCrackTimeForModel(password string, modelType AttackModelType) string {
+	model := GetAttackModels()[modelType]
+	return GetCrackTimeDescription(CrackSecondsForModel(g.AnalyzeStrength(password), model))
+}
+
+func (g *Generator) GenerateRandomChar() byte {
+	var allChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!@#$%^&*()_+-="
+	// nosec G404 -- only used for visual animation
+	return allChars[rand.Intn(len(allChars))]
 }
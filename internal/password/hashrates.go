@@ -0,0 +1,253 @@
+package password
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hash algorithms attack models can name, each with the hashcat mode it is
+// benchmarked as and the work factor that benchmark uses
+type hashAlgorithm struct {
+	name string
+	mode int
+	// one RTX 4090 at the reference work factor, from published hashcat
+	// benchmarks; import your own with `datflux models import`
+	rate float64
+}
+
+var hashAlgorithms = []hashAlgorithm{
+	{"md5", 0, 164.1e9},
+	{"ntlm", 1000, 288.5e9},
+	{"sha512crypt", 1800, 3.05e6}, // 5000 rounds
+	{"bcrypt", 3200, 184e3},       // cost 5
+	{"argon2id", 34000, 1.0e3},    // 64 MiB, 3 iterations, a rough figure
+}
+
+// reference work factors the rates above are measured at
+const (
+	referenceSHA512Rounds   = 5000
+	referenceBcryptCost     = 5
+	referenceArgon2MemoryKB = 65536
+	referenceArgon2Passes   = 3
+)
+
+// guesses per second of one GPU at the reference work factor, by algorithm
+type HashRates map[string]float64
+
+func DefaultHashRates() HashRates {
+	rates := make(HashRates, len(hashAlgorithms))
+	for _, algorithm := range hashAlgorithms {
+		rates[algorithm.name] = algorithm.rate
+	}
+	return rates
+}
+
+// names attack models accept for hash, in display order
+func HashAlgorithms() []string {
+	names := make([]string, len(hashAlgorithms))
+	for i, algorithm := range hashAlgorithms {
+		names[i] = algorithm.name
+	}
+	return names
+}
+
+func lookupHashAlgorithm(name string) (hashAlgorithm, bool) {
+	for _, algorithm := range hashAlgorithms {
+		if algorithm.name == name {
+			return algorithm, true
+		}
+	}
+	return hashAlgorithm{}, false
+}
+
+// the hash an attacked system stores and its work factor, zero fields take
+// the usual defaults (sha512crypt 5000 rounds, bcrypt cost 10, argon2id
+// 64 MiB with 3 iterations and 1 lane)
+type HashParams struct {
+	Algorithm   string
+	Cost        int // bcrypt
+	Rounds      int // sha512crypt
+	MemoryKB    int // argon2id
+	Iterations  int // argon2id
+	Parallelism int // argon2id, lanes share the work, the rate is unchanged
+}
+
+func (p HashParams) withDefaults() HashParams {
+	switch p.Algorithm {
+	case "sha512crypt":
+		if p.Rounds == 0 {
+			p.Rounds = referenceSHA512Rounds
+		}
+	case "bcrypt":
+		if p.Cost == 0 {
+			p.Cost = 10
+		}
+	case "argon2id":
+		if p.MemoryKB == 0 {
+			p.MemoryKB = referenceArgon2MemoryKB
+		}
+		if p.Iterations == 0 {
+			p.Iterations = referenceArgon2Passes
+		}
+		if p.Parallelism == 0 {
+			p.Parallelism = 1
+		}
+	}
+	return p
+}
+
+func (p HashParams) Validate() error {
+	if _, ok := lookupHashAlgorithm(p.Algorithm); !ok {
+		return fmt.Errorf("unknown hash %q (md5, ntlm, sha512crypt, bcrypt, argon2id)", p.Algorithm)
+	}
+
+	p = p.withDefaults()
+	switch {
+	case p.Algorithm == "bcrypt" && (p.Cost < 4 || p.Cost > 31):
+		return fmt.Errorf("bcrypt cost must be between 4 and 31 (got %d)", p.Cost)
+	case p.Algorithm == "sha512crypt" && (p.Rounds < 1000 || p.Rounds > 999999999):
+		return fmt.Errorf("sha512crypt rounds must be between 1000 and 999999999 (got %d)", p.Rounds)
+	case p.Algorithm == "argon2id" && (p.MemoryKB < 8 || p.Iterations < 1 || p.Parallelism < 1):
+		return fmt.Errorf("argon2id needs memory >= 8 KiB, iterations and parallelism >= 1")
+	}
+	return nil
+}
+
+// guesses per second of one GPU against this hash, scaled from the
+// reference rate by the work factor
+func (p HashParams) Rate(rates HashRates) float64 {
+	p = p.withDefaults()
+	rate := rates[p.Algorithm]
+
+	switch p.Algorithm {
+	case "sha512crypt":
+		rate *= float64(referenceSHA512Rounds) / float64(p.Rounds)
+	case "bcrypt":
+		rate *= math.Pow(2, float64(referenceBcryptCost-p.Cost))
+	case "argon2id":
+		rate *= float64(referenceArgon2MemoryKB*referenceArgon2Passes) / float64(p.MemoryKB*p.Iterations)
+	}
+	return rate
+}
+
+// e.g. "bcrypt cost 12" or "argon2id 64 MiB, t=3, p=4"
+func (p HashParams) String() string {
+	p = p.withDefaults()
+	switch p.Algorithm {
+	case "sha512crypt":
+		return fmt.Sprintf("sha512crypt %d rounds", p.Rounds)
+	case "bcrypt":
+		return fmt.Sprintf("bcrypt cost %d", p.Cost)
+	case "argon2id":
+		return fmt.Sprintf("argon2id %d MiB, t=%d, p=%d", p.MemoryKB/1024, p.Iterations, p.Parallelism)
+	}
+	return p.Algorithm
+}
+
+var (
+	hashcatModeLine  = regexp.MustCompile(`^\* Hash-Mode (\d+) \(.*?\)(?: \[Iterations: (\d+)\])?`)
+	hashcatSpeedLine = regexp.MustCompile(`^Speed\.#(\d+|\*)\.*:\s*([\d.]+)\s*([kMGTP]?)H/s`)
+)
+
+var rateUnits = map[string]float64{"": 1, "k": 1e3, "M": 1e6, "G": 1e9, "T": 1e12, "P": 1e15}
+
+// per-GPU rates from `hashcat --benchmark` output, normalised to the
+// reference work factors; modes datflux has no use for are skipped
+func ParseHashcatBenchmark(r io.Reader) (HashRates, error) {
+	rates := make(HashRates)
+
+	type modeSpeeds struct {
+		algorithm  hashAlgorithm
+		iterations int
+		devices    []float64
+		total      float64
+	}
+	var current *modeSpeeds
+
+	finish := func() {
+		if current == nil || (len(current.devices) == 0 && current.total == 0) {
+			return
+		}
+
+		// the combined speed is split evenly, otherwise devices are averaged
+		perDevice := current.total
+		if perDevice == 0 {
+			for _, speed := range current.devices {
+				perDevice += speed
+			}
+		}
+		perDevice /= float64(max(len(current.devices), 1))
+
+		switch current.algorithm.name {
+		case "sha512crypt":
+			if current.iterations > 0 {
+				perDevice *= float64(current.iterations) / referenceSHA512Rounds
+			}
+		case "bcrypt":
+			if current.iterations > 0 {
+				perDevice *= float64(current.iterations) / math.Pow(2, referenceBcryptCost)
+			}
+		}
+		rates[current.algorithm.name] = perDevice
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if match := hashcatModeLine.FindStringSubmatch(line); match != nil {
+			finish()
+			current = nil
+
+			mode, _ := strconv.Atoi(match[1])
+			for _, algorithm := range hashAlgorithms {
+				if algorithm.mode == mode {
+					iterations, _ := strconv.Atoi(match[2])
+					current = &modeSpeeds{algorithm: algorithm, iterations: iterations}
+				}
+			}
+			continue
+		}
+
+		match := hashcatSpeedLine.FindStringSubmatch(line)
+		if match == nil || current == nil {
+			continue
+		}
+		speed, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			continue
+		}
+		speed *= rateUnits[match[3]]
+
+		if match[1] == "*" {
+			current.total = speed
+		} else {
+			current.devices = append(current.devices, speed)
+		}
+	}
+	finish()
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("no md5, ntlm, sha512crypt, bcrypt or argon2id benchmark found")
+	}
+	return rates, nil
+}
+
+// e.g. "164.1 GH/s"
+func FormatRate(guessesPerSec float64) string {
+	units := []string{"", "k", "M", "G", "T", "P", "E"}
+	unit := 0
+	for guessesPerSec >= 1000 && unit < len(units)-1 {
+		guessesPerSec /= 1000
+		unit++
+	}
+	return fmt.Sprintf("%.4g %sH/s", guessesPerSec, units[unit])
+}
@@ -0,0 +1,136 @@
+package password
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// minimal INI reader shared by profiles.conf and pwquality.conf
+// keys before the first [section] land in the unnamed section
+type iniEntry struct {
+	Key   string
+	Value string
+	Line  int
+}
+
+type iniSection struct {
+	Name    string
+	Entries []iniEntry
+}
+
+func parseINI(r io.Reader) ([]iniSection, error) {
+	sections := []iniSection{{Name: ""}}
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated section header", lineNumber)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if name == "" {
+				return nil, fmt.Errorf("line %d: empty section name", lineNumber)
+			}
+			sections = append(sections, iniSection{Name: name})
+			continue
+		}
+
+		// bare keys (pwquality's "enforce_for_root") have an empty value
+		key, value, _ := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("line %d: missing key", lineNumber)
+		}
+
+		current := &sections[len(sections)-1]
+		current.Entries = append(current.Entries, iniEntry{
+			Key:   key,
+			Value: unquoteINIValue(strings.TrimSpace(value)),
+			Line:  lineNumber,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return sections, nil
+}
+
+// values may be wrapped in double quotes to keep leading or trailing spaces
+func unquoteINIValue(value string) string {
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// quotes values that would not survive a round trip otherwise
+func quoteINIValue(value string) string {
+	if value != strings.TrimSpace(value) || strings.HasPrefix(value, `"`) {
+		return `"` + value + `"`
+	}
+	return value
+}
+
+// sets key = value inside [section], appending the section if needed
+// every other line, comments included, is kept as is
+func setINIValue(content, section, key, value string) string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	newLine := fmt.Sprintf("%s = %s", key, quoteINIValue(value))
+	header := -1
+	end := len(lines)
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "[") || !strings.HasSuffix(trimmed, "]") {
+			continue
+		}
+
+		if header >= 0 {
+			end = i
+			break
+		}
+		if strings.TrimSpace(trimmed[1:len(trimmed)-1]) == section {
+			header = i
+		}
+	}
+
+	if header < 0 {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "["+section+"]", newLine)
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	for i := header + 1; i < end; i++ {
+		existingKey, _, found := strings.Cut(strings.TrimSpace(lines[i]), "=")
+		if found && strings.TrimSpace(existingKey) == key {
+			lines[i] = newLine
+			return strings.Join(lines, "\n") + "\n"
+		}
+	}
+
+	// insert after the last non-blank line of the section
+	insertAt := end
+	for insertAt > header+1 && strings.TrimSpace(lines[insertAt-1]) == "" {
+		insertAt--
+	}
+	lines = append(lines[:insertAt], append([]string{newLine}, lines[insertAt:]...)...)
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,75 @@
+package password
+
+import (
+	"fmt"
+	"math"
+)
+
+// how paranoia mode draws and picks its passwords
+type ParanoiaOptions struct {
+	Candidates    int // drawn per password, the best zxcvbn score is kept, 1 = no selection
+	MinLength     int
+	MaxLength     int
+	ShufflePasses int
+}
+
+const (
+	maxParanoiaCandidates = 1000
+	maxShufflePasses      = 16
+)
+
+// best of 25, 48-80 chars, 3 shuffle passes
+func DefaultParanoiaOptions() ParanoiaOptions {
+	return ParanoiaOptions{
+		Candidates:    25,
+		MinLength:     48,
+		MaxLength:     80,
+		ShufflePasses: 3,
+	}
+}
+
+func (o ParanoiaOptions) Validate() error {
+	switch {
+	case o.Candidates < 1 || o.Candidates > maxParanoiaCandidates:
+		return fmt.Errorf("candidates must be between 1 and %d (got %d)", maxParanoiaCandidates, o.Candidates)
+	case o.MinLength < 1 || o.MaxLength < o.MinLength:
+		return fmt.Errorf("invalid paranoia length range %d-%d", o.MinLength, o.MaxLength)
+	case o.MaxLength > MaxPolicyLength:
+		return fmt.Errorf("paranoia length %d exceeds %d", o.MaxLength, MaxPolicyLength)
+	case o.ShufflePasses < 0 || o.ShufflePasses > maxShufflePasses:
+		return fmt.Errorf("shuffle passes must be between 0 and %d (got %d)", maxShufflePasses, o.ShufflePasses)
+	}
+	return nil
+}
+
+// bits that keeping the best of N candidates can cost, assuming the
+// score never ties (ties only make the selection cheaper)
+//
+// the top-ranked passwords become N times likelier, so min-entropy
+// drops by log2 N; the chosen rank follows Beta(N, 1), so Shannon
+// entropy drops by log2 N - (N-1)/(N ln 2)
+func (o ParanoiaOptions) SelectionCost() (shannon, minEntropy float64) {
+	if o.Candidates <= 1 {
+		return 0, 0
+	}
+
+	n := float64(o.Candidates)
+	minEntropy = math.Log2(n)
+	shannon = minEntropy - (n-1)/(n*math.Ln2)
+	return shannon, minEntropy
+}
+
+// e.g. "best of 25, 48-80 chars, 3 shuffles"
+func (o ParanoiaOptions) String() string {
+	selection := fmt.Sprintf("best of %d", o.Candidates)
+	if o.Candidates == 1 {
+		selection = "no selection"
+	}
+
+	length := fmt.Sprintf("%d-%d", o.MinLength, o.MaxLength)
+	if o.MinLength == o.MaxLength {
+		length = fmt.Sprintf("%d", o.MinLength)
+	}
+
+	return fmt.Sprintf("%s, %s chars, %d shuffles", selection, length, o.ShufflePasses)
+}
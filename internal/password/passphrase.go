@@ -0,0 +1,139 @@
+package password
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// EFF large wordlist, 7776 words (five dice rolls each), 7772 once the
+// hyphenated ones are dropped
+// https://www.eff.org/files/2016/07/18/eff_large_wordlist.txt
+//
+//go:embed wordlists/eff_large_wordlist.txt
+var effLargeWordlistData string
+
+const EFFLargeWordlistName = "eff-large"
+
+type Wordlist struct {
+	Name  string
+	Words []string
+}
+
+// bits contributed by each word drawn uniformly from the list
+func (w *Wordlist) EntropyPerWord() float64 {
+	if len(w.Words) == 0 {
+		return 0
+	}
+	return math.Log2(float64(len(w.Words)))
+}
+
+var effLargeWordlist = sync.OnceValue(func() *Wordlist {
+	var words []string
+	for _, line := range strings.Split(effLargeWordlistData, "\n") {
+		// "11111<tab>abacus", the dice rolls are not needed
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if word := normalizeWord(fields[len(fields)-1]); word != "" {
+			words = append(words, word)
+		}
+	}
+
+	return &Wordlist{Name: EFFLargeWordlistName, Words: words}
+})
+
+func EFFLargeWordlist() *Wordlist {
+	return effLargeWordlist()
+}
+
+const (
+	MinPassphraseWords = 3
+	MaxPassphraseWords = 64
+)
+
+type PassphraseOptions struct {
+	Words       int
+	Separator   string
+	Capitalize  bool // title-case every word, adds no entropy
+	InsertDigit bool // one random digit appended to one random word
+}
+
+// 6 EFF words, ~77.5 bits
+func DefaultPassphraseOptions() PassphraseOptions {
+	return PassphraseOptions{
+		Words:     6,
+		Separator: "-",
+	}
+}
+
+func (o PassphraseOptions) Validate() error {
+	if o.Words < MinPassphraseWords || o.Words > MaxPassphraseWords {
+		return fmt.Errorf("%w: passphrase needs %d-%d words (got %d)",
+			ErrImpossiblePolicy, MinPassphraseWords, MaxPassphraseWords, o.Words)
+	}
+	// words are letters only, a separator with letters could join two
+	// word sequences into the same passphrase
+	if strings.ContainsFunc(o.Separator, func(char rune) bool {
+		return unicode.IsLetter(char) || unicode.IsMark(char)
+	}) {
+		return fmt.Errorf("%w: separator %q contains letters, passphrases could read as other words",
+			ErrImpossiblePolicy, o.Separator)
+	}
+	return nil
+}
+
+// exact bits of a passphrase drawn from the given list
+// the separator is fixed and capitalisation deterministic, so only
+// the words and the optional digit (value and position) count
+func (o PassphraseOptions) Entropy(wordlist *Wordlist) float64 {
+	bits := float64(o.Words) * wordlist.EntropyPerWord()
+	if o.InsertDigit {
+		bits += math.Log2(10) + math.Log2(float64(o.Words))
+	}
+	return bits
+}
+
+func (o PassphraseOptions) String() string {
+	extras := ""
+	if o.Capitalize {
+		extras += ", capitalised"
+	}
+	if o.InsertDigit {
+		extras += ", digit"
+	}
+	return fmt.Sprintf("%d words, separator %q%s", o.Words, o.Separator, extras)
+}
+
+// draws every word, the digit and its position from the sampler
+func generatePassphrase(s *sampler, wordlist *Wordlist, opts PassphraseOptions) string {
+	words := make([]string, opts.Words)
+	for i := range words {
+		words[i] = wordlist.Words[s.Intn(len(wordlist.Words))]
+
+		if opts.Capitalize {
+			words[i] = capitalize(words[i])
+		}
+	}
+
+	if opts.InsertDigit {
+		digit := digitChars[s.Intn(len(digitChars))]
+		position := s.Intn(len(words))
+		words[position] += string(digit)
+	}
+
+	return strings.Join(words, opts.Separator)
+}
+
+func capitalize(word string) string {
+	first, size := utf8.DecodeRuneInString(word)
+	if first == utf8.RuneError {
+		return word
+	}
+	return string(unicode.ToUpper(first)) + word[size:]
+}
@@ -0,0 +1,343 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// returned when a policy cannot produce any password, or none that can
+// be counted to keep the output uniform
+var ErrImpossiblePolicy = errors.New("impossible password policy")
+
+// upper bound for a single password, keeps the TUI and terminals sane
+const MaxPolicyLength = 1024
+
+// what the generator is allowed to produce in standard mode
+type Policy struct {
+	MinLength int
+	MaxLength int
+	Symbols   bool
+	Digits    bool
+	Upper     bool
+	Lower     bool
+
+	Charset     string // preset name, see CharsetPresets
+	CustomChars string // literal alphabet, overrides Charset
+	Exclude     string // characters removed from every class
+
+	MaxRepeat       int  // longest run of one identical char, 0 = unlimited
+	MaxClassRepeat  int  // longest run of one character class, 0 = unlimited
+	MaxSequence     int  // longest ascending, descending or keyboard run, 0 = unlimited
+	MaxOccurrences  int  // times any one char may appear, 0 = unlimited
+	StartWithLetter bool // first char must be a letter
+
+	// chars required from each enabled class, 0 means one
+	MinLower   int
+	MinUpper   int
+	MinDigits  int
+	MinSymbols int
+}
+
+// rejection sampling below this acceptance rate takes too many draws
+const minAcceptance = 1e-3
+
+// most draws needed before giving up, unreachable for valid policies
+const maxRejections = 100000
+
+// 16-32 chars with every character class
+func DefaultPolicy() Policy {
+	return Policy{
+		MinLength: 16,
+		MaxLength: 32,
+		Symbols:   true,
+		Digits:    true,
+		Upper:     true,
+		Lower:     true,
+		Charset:   DefaultCharsetName,
+	}
+}
+
+func (p Policy) resolveCharset() (Charset, error) {
+	if p.CustomChars != "" {
+		return CustomCharset(p.CustomChars), nil
+	}
+
+	name := p.Charset
+	if name == "" {
+		name = DefaultCharsetName
+	}
+
+	charset, ok := LookupCharset(name)
+	if !ok {
+		return Charset{}, fmt.Errorf("unknown charset %q", name)
+	}
+	return charset, nil
+}
+
+// enabled, non-empty classes after exclusions
+// each one contributes its required chars to every password
+func (p Policy) Classes() []CharClass {
+	charset, err := p.resolveCharset()
+	if err != nil {
+		return nil
+	}
+
+	candidates := []struct {
+		enabled bool
+		class   CharClass
+	}{
+		{p.Lower, CharClass{"lowercase", charset.Lower, max(1, p.MinLower)}},
+		{p.Upper, CharClass{"uppercase", charset.Upper, max(1, p.MinUpper)}},
+		{p.Digits, CharClass{"digits", charset.Digits, max(1, p.MinDigits)}},
+		{p.Symbols, CharClass{"symbols", charset.Symbols, max(1, p.MinSymbols)}},
+	}
+
+	var classes []CharClass
+	for _, candidate := range candidates {
+		if !candidate.enabled {
+			continue
+		}
+
+		chars := removeChars(candidate.class.Chars, p.Exclude)
+		if chars != "" {
+			classes = append(classes, CharClass{candidate.class.Name, chars, candidate.class.Min})
+		}
+	}
+
+	return classes
+}
+
+func (p Policy) ClassCount() int {
+	return len(p.Classes())
+}
+
+// chars every password spends on class minimums
+func (p Policy) RequiredCount() int {
+	required := 0
+	for _, class := range p.Classes() {
+		required += class.Min
+	}
+	return required
+}
+
+// full alphabet the generator draws from
+func (p Policy) Alphabet() string {
+	var builder strings.Builder
+	for _, class := range p.Classes() {
+		builder.WriteString(class.Chars)
+	}
+	return builder.String()
+}
+
+func (p Policy) AlphabetSize() int {
+	return len(p.Alphabet())
+}
+
+// theoretical entropy of a password of the given length
+// drawn from this policy's alphabet (log2 of alphabet size per char)
+func (p Policy) TheoreticalEntropy(length int) float64 {
+	size := p.AlphabetSize()
+	if size == 0 {
+		return 0
+	}
+	return float64(length) * math.Log2(float64(size))
+}
+
+func (p Policy) Validate() error {
+	if _, err := p.resolveCharset(); err != nil {
+		return fmt.Errorf("%w: %v", ErrImpossiblePolicy, err)
+	}
+
+	switch {
+	case p.MinLength < 1:
+		return fmt.Errorf("%w: minimum length must be at least 1 (got %d)", ErrImpossiblePolicy, p.MinLength)
+	case p.MaxLength < p.MinLength:
+		return fmt.Errorf("%w: maximum length %d is below minimum length %d", ErrImpossiblePolicy, p.MaxLength, p.MinLength)
+	case p.MaxLength > MaxPolicyLength:
+		return fmt.Errorf("%w: maximum length %d exceeds %d", ErrImpossiblePolicy, p.MaxLength, MaxPolicyLength)
+	case p.ClassCount() == 0:
+		return fmt.Errorf("%w: every character class is disabled or excluded", ErrImpossiblePolicy)
+	case p.MinLower < 0 || p.MinUpper < 0 || p.MinDigits < 0 || p.MinSymbols < 0:
+		return fmt.Errorf("%w: class minimums must be positive", ErrImpossiblePolicy)
+	case p.MaxLength < p.RequiredCount():
+		return fmt.Errorf("%w: %d required characters do not fit in %d chars",
+			ErrImpossiblePolicy, p.RequiredCount(), p.MaxLength)
+	case p.MaxRepeat < 0 || p.MaxClassRepeat < 0 || p.MaxSequence < 0 || p.MaxOccurrences < 0:
+		return fmt.Errorf("%w: repeat, sequence and occurrence limits must be positive", ErrImpossiblePolicy)
+	case p.uncountable():
+		return fmt.Errorf("%w: max occurrences can't be combined with max repeat, max class repeat or max sequence, the valid passwords could not be counted exactly",
+			ErrImpossiblePolicy)
+	case p.StartWithLetter && !strings.ContainsFunc(p.Alphabet(), isLetter):
+		return fmt.Errorf("%w: must start with a letter but the alphabet has none", ErrImpossiblePolicy)
+	}
+
+	// a minimum on a missing class can never be met
+	enabled := map[string]bool{}
+	for _, class := range p.Classes() {
+		enabled[class.Name] = true
+	}
+	for _, minimum := range []struct {
+		name  string
+		count int
+	}{
+		{"lowercase", p.MinLower}, {"uppercase", p.MinUpper},
+		{"digits", p.MinDigits}, {"symbols", p.MinSymbols},
+	} {
+		if minimum.count > 0 && !enabled[minimum.name] {
+			return fmt.Errorf("%w: %d %s required but the class is disabled", ErrImpossiblePolicy, minimum.count, minimum.name)
+		}
+	}
+
+	space := p.space()
+	switch {
+	case math.IsInf(space.log2Total, -1):
+		return fmt.Errorf("%w: no password of %s satisfies every rule", ErrImpossiblePolicy, p.lengthRange())
+	case space.acceptance(p.MaxLength) < minAcceptance:
+		return fmt.Errorf("%w: only %.2g%% of %s passwords from %d symbols pass the rules",
+			ErrImpossiblePolicy, 100*space.acceptance(p.MaxLength), p.lengthRange(), p.AlphabetSize())
+	}
+
+	return nil
+}
+
+// checks every rule, generation rejects uniform draws that fail
+func (p Policy) Accepts(password string) bool {
+	if p.StartWithLetter && (password == "" || !isLetter([]rune(password)[0])) {
+		return false
+	}
+	if p.MaxRepeat > 0 && longestRun(password, identity) > p.MaxRepeat {
+		return false
+	}
+	if p.MaxClassRepeat > 0 && longestRun(password, classOf) > p.MaxClassRepeat {
+		return false
+	}
+	if p.MaxSequence > 0 && longestSequence(password) > p.MaxSequence {
+		return false
+	}
+	if p.MaxOccurrences > 0 && mostOccurrences(password) > p.MaxOccurrences {
+		return false
+	}
+
+	for _, class := range p.Classes() {
+		count := 0
+		for _, char := range password {
+			if strings.ContainsRune(class.Chars, char) {
+				count++
+			}
+		}
+		if count < class.Min {
+			return false
+		}
+	}
+	return true
+}
+
+// every policy rule the password breaks, unlike Accepts it also checks
+// the length and alphabet, which generated passwords always satisfy
+func (p Policy) Violations(password string) []string {
+	var violations []string
+
+	length := len([]rune(password))
+	if length < p.MinLength || length > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("length %d outside %s", length, p.lengthRange()))
+	}
+
+	alphabet := p.Alphabet()
+	for _, char := range password {
+		if !strings.ContainsRune(alphabet, char) {
+			violations = append(violations, "uses characters outside the policy alphabet")
+			break
+		}
+	}
+
+	if p.StartWithLetter && (password == "" || !isLetter([]rune(password)[0])) {
+		violations = append(violations, "doesn't start with a letter")
+	}
+	if p.MaxRepeat > 0 && longestRun(password, identity) > p.MaxRepeat {
+		violations = append(violations, fmt.Sprintf("repeats a character more than %d times in a row", p.MaxRepeat))
+	}
+	if p.MaxClassRepeat > 0 && longestRun(password, classOf) > p.MaxClassRepeat {
+		violations = append(violations, fmt.Sprintf("has more than %d characters of one class in a row", p.MaxClassRepeat))
+	}
+	if p.MaxSequence > 0 && longestSequence(password) > p.MaxSequence {
+		violations = append(violations, fmt.Sprintf("has a sequence longer than %d", p.MaxSequence))
+	}
+	if p.MaxOccurrences > 0 && mostOccurrences(password) > p.MaxOccurrences {
+		violations = append(violations, fmt.Sprintf("uses a character more than %d times", p.MaxOccurrences))
+	}
+
+	for _, class := range p.Classes() {
+		count := 0
+		for _, char := range password {
+			if strings.ContainsRune(class.Chars, char) {
+				count++
+			}
+		}
+		if count < class.Min {
+			violations = append(violations, fmt.Sprintf("has fewer than %d %s", class.Min, class.Name))
+		}
+	}
+
+	return violations
+}
+
+func identity(char rune) int {
+	return int(char)
+}
+
+// longest run of chars that map to the same key
+func longestRun(password string, key func(rune) int) int {
+	longest, current := 0, 0
+	previous := -1
+
+	for _, char := range password {
+		if k := key(char); k == previous {
+			current++
+		} else {
+			current = 1
+			previous = k
+		}
+		longest = max(longest, current)
+	}
+
+	return longest
+}
+
+// short human-readable form, e.g. "12-20 chars, alnum (62)"
+func (p Policy) String() string {
+	name := p.Charset
+	if name == "" {
+		name = DefaultCharsetName
+	}
+	if p.CustomChars != "" {
+		name = "custom"
+	}
+
+	description := fmt.Sprintf("%s chars, %s (%d)", p.lengthRange(), name, p.AlphabetSize())
+	if p.MaxRepeat > 0 {
+		description += fmt.Sprintf(", max repeat %d", p.MaxRepeat)
+	}
+	if p.MaxClassRepeat > 0 {
+		description += fmt.Sprintf(", max class repeat %d", p.MaxClassRepeat)
+	}
+	if p.MaxSequence > 0 {
+		description += fmt.Sprintf(", max sequence %d", p.MaxSequence)
+	}
+	if p.MaxOccurrences > 0 {
+		description += fmt.Sprintf(", max %d of a char", p.MaxOccurrences)
+	}
+	if p.StartWithLetter {
+		description += ", starts with a letter"
+	}
+	return description
+}
+
+// "16" or "12-20"
+func (p Policy) lengthRange() string {
+	if p.MinLength == p.MaxLength {
+		return fmt.Sprintf("%d", p.MinLength)
+	}
+	return fmt.Sprintf("%d-%d", p.MinLength, p.MaxLength)
+}
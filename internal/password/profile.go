@@ -0,0 +1,287 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"datflux/internal/entropy"
+)
+
+// named generator settings from <config dir>/profiles.conf
+// a profile either dictates a template or a character policy
+//
+//	[licence]
+//	pattern = X{4}-X{4}-X{4}
+//
+//	[bank]
+//	length = 12-20
+//	require = lower, upper, digits
+//	forbid = 0O1lI
+//	max_repeat = 2
+//	max_sequence = 3
+//	start_letter = true
+//	charset = alnum
+//	user_inputs = acme, jdoe
+//	dictionary = bank-words.txt
+//
+// user_inputs and dictionary (one word per line, relative to the profile
+// file) name words zxcvbn should try first, they fit either kind
+type Profile struct {
+	Name       string
+	Pattern    string
+	Policy     Policy
+	UserInputs []string
+
+	hasPolicy  bool   // any policy key was set
+	dictionary string // as written, resolved by LoadProfiles
+}
+
+func ProfilesPath() string {
+	return filepath.Join(entropy.ConfigDir(), "profiles.conf")
+}
+
+// template profiles generate from their pattern, the rest from their policy
+func (p Profile) Mode() Mode {
+	if p.Pattern != "" {
+		return ModeTemplate
+	}
+	return ModeCharacters
+}
+
+func (p Profile) Validate() error {
+	switch {
+	case p.Pattern != "" && p.hasPolicy:
+		return fmt.Errorf("profile %q mixes a pattern with policy keys", p.Name)
+	case p.Pattern != "":
+		if _, err := ParseTemplate(p.Pattern); err != nil {
+			return fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+	default:
+		if err := p.Policy.Validate(); err != nil {
+			return fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+	}
+	return nil
+}
+
+// missing file means no profiles, not an error
+func LoadProfiles(path string) ([]Profile, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sections, err := parseINI(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var profiles []Profile
+	for _, section := range sections {
+		if section.Name == "" {
+			continue
+		}
+
+		profile, err := profileFromSection(path, section)
+		if err != nil {
+			return nil, err
+		}
+
+		if profile.dictionary != "" {
+			dictionary := profile.dictionary
+			if !filepath.IsAbs(dictionary) {
+				dictionary = filepath.Join(filepath.Dir(path), dictionary)
+			}
+			words, err := LoadUserInputs(dictionary)
+			if err != nil {
+				return nil, fmt.Errorf("%s: profile %q: %w", path, profile.Name, err)
+			}
+			profile.UserInputs = append(profile.UserInputs, words...)
+		}
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, nil
+}
+
+// a section's keys as a profile, the dictionary still unresolved
+func profileFromSection(path string, section iniSection) (Profile, error) {
+	profile := Profile{Name: section.Name, Policy: DefaultPolicy()}
+	for _, entry := range section.Entries {
+		if err := profile.set(entry.Key, entry.Value); err != nil {
+			return Profile{}, fmt.Errorf("%s:%d: %w", path, entry.Line, err)
+		}
+	}
+	return profile, nil
+}
+
+func (p *Profile) set(key, value string) error {
+	switch key {
+	case "pattern", "user_inputs", "dictionary":
+	default:
+		p.hasPolicy = true
+	}
+
+	policy := &p.Policy
+	switch key {
+	case "pattern":
+		p.Pattern = value
+
+	case "user_inputs":
+		for _, word := range strings.Split(value, ",") {
+			if word = strings.TrimSpace(word); word != "" {
+				p.UserInputs = append(p.UserInputs, word)
+			}
+		}
+	case "dictionary":
+		p.dictionary = value
+
+	case "length":
+		// "16" or "12-20"
+		low, high, isRange := strings.Cut(value, "-")
+		if !isRange {
+			high = low
+		}
+		minLength, err := parseProfileInt(key, low)
+		if err != nil {
+			return err
+		}
+		maxLength, err := parseProfileInt(key, high)
+		if err != nil {
+			return err
+		}
+		policy.MinLength, policy.MaxLength = minLength, maxLength
+
+	case "min":
+		return parseProfileIntInto(key, value, &policy.MinLength)
+	case "max":
+		return parseProfileIntInto(key, value, &policy.MaxLength)
+	case "max_repeat":
+		return parseProfileIntInto(key, value, &policy.MaxRepeat)
+	case "max_class_repeat":
+		return parseProfileIntInto(key, value, &policy.MaxClassRepeat)
+	case "max_sequence":
+		return parseProfileIntInto(key, value, &policy.MaxSequence)
+	case "max_occurrences":
+		return parseProfileIntInto(key, value, &policy.MaxOccurrences)
+	case "start_letter":
+		startLetter, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not true or false", key, value)
+		}
+		policy.StartWithLetter = startLetter
+
+	case "require":
+		policy.Lower, policy.Upper, policy.Digits, policy.Symbols = false, false, false, false
+		for _, class := range strings.Split(value, ",") {
+			switch strings.TrimSpace(class) {
+			case "lower":
+				policy.Lower = true
+			case "upper":
+				policy.Upper = true
+			case "digits":
+				policy.Digits = true
+			case "symbols":
+				policy.Symbols = true
+			case "":
+			default:
+				return fmt.Errorf("unknown class %q (lower, upper, digits, symbols)", strings.TrimSpace(class))
+			}
+		}
+
+	case "charset":
+		if _, ok := LookupCharset(value); !ok {
+			return fmt.Errorf("unknown charset %q", value)
+		}
+		policy.Charset = value
+	case "chars":
+		policy.CustomChars = value
+	case "forbid":
+		policy.Exclude = value
+
+	default:
+		return fmt.Errorf("unknown profile key %q", key)
+	}
+
+	return nil
+}
+
+func parseProfileInt(key, value string) (int, error) {
+	number, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %q is not a number", key, value)
+	}
+	return number, nil
+}
+
+func parseProfileIntInto(key, value string, target *int) error {
+	number, err := parseProfileInt(key, value)
+	if err != nil {
+		return err
+	}
+	*target = number
+	return nil
+}
+
+func FindProfile(path, name string) (Profile, error) {
+	profiles, err := LoadProfiles(path)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	for _, profile := range profiles {
+		if profile.Name == name {
+			return profile, profile.Validate()
+		}
+	}
+	return Profile{}, fmt.Errorf("profile %q not found in %s", name, path)
+}
+
+// writes the profile's pattern into its section, keeping the rest of the file
+func SaveProfile(path string, profile Profile) error {
+	if strings.ContainsAny(profile.Name, "[]\n") || strings.TrimSpace(profile.Name) == "" {
+		return fmt.Errorf("invalid profile name %q", profile.Name)
+	}
+	if profile.Pattern == "" {
+		return fmt.Errorf("only template profiles can be saved, edit %s for policies", path)
+	}
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	updated := setINIValue(string(content), profile.Name, "pattern", profile.Pattern)
+
+	// the pattern joins whatever the section already holds, which has to
+	// load again; a policy profile can't take a pattern
+	sections, err := parseINI(strings.NewReader(updated))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, section := range sections {
+		if section.Name != profile.Name {
+			continue
+		}
+		merged, err := profileFromSection(path, section)
+		if err == nil {
+			err = merged.Validate()
+		}
+		if err != nil {
+			return fmt.Errorf("not saved, %w", err)
+		}
+	}
+
+	return os.WriteFile(path, []byte(updated), 0600)
+}
@@ -0,0 +1,109 @@
+package password
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// phonotactic model: every syllable is one onset followed by one nucleus,
+// and the word may end in one coda
+// onsets and codas only use consonants and nuclei only use vowels, so each
+// consonant run maps back to exactly one unit and every output has exactly
+// one derivation, which is what makes the entropy figure exact
+var (
+	pronounceableOnsets = []string{
+		"b", "c", "d", "f", "g", "h", "j", "k", "l", "m", "n", "p", "r", "s", "t", "v", "w", "z",
+		"bl", "br", "ch", "cl", "cr", "dr", "fl", "fr", "gl", "gr", "kl", "kr",
+		"pl", "pr", "sc", "sh", "sk", "sl", "sm", "sn", "sp", "st", "sw", "th", "tr", "tw",
+	}
+	pronounceableNuclei = []string{
+		"a", "e", "i", "o", "u",
+		"ai", "au", "ea", "ee", "ei", "ie", "oa", "oi", "oo", "ou",
+	}
+	pronounceableCodas = []string{
+		"", "b", "d", "f", "g", "k", "l", "m", "n", "p", "r", "s", "t", "x",
+		"ck", "ft", "lk", "lt", "mp", "nd", "nk", "nt", "rd", "rk", "rn", "rt", "sk", "st",
+	}
+)
+
+const (
+	MinPronounceableSyllables = 2
+	MaxPronounceableSyllables = 32
+)
+
+type PronounceableOptions struct {
+	Syllables  int
+	Capitalize bool // upper-cases the first letter of one random syllable
+	Digits     int  // random digits appended at the end
+}
+
+// 7 syllables plus a coda, ~70 bits
+func DefaultPronounceableOptions() PronounceableOptions {
+	return PronounceableOptions{
+		Syllables: 7,
+	}
+}
+
+func (o PronounceableOptions) Validate() error {
+	if o.Syllables < MinPronounceableSyllables || o.Syllables > MaxPronounceableSyllables {
+		return fmt.Errorf("%w: pronounceable passwords need %d-%d syllables (got %d)",
+			ErrImpossiblePolicy, MinPronounceableSyllables, MaxPronounceableSyllables, o.Syllables)
+	}
+	if o.Digits < 0 || o.Digits > 16 {
+		return fmt.Errorf("%w: 0-16 trailing digits allowed (got %d)", ErrImpossiblePolicy, o.Digits)
+	}
+	return nil
+}
+
+// exact bits of the generation process, not zxcvbn's estimate
+// sums log2 of every uniform choice, valid because outputs are unambiguous
+func (o PronounceableOptions) Entropy() float64 {
+	syllableBits := math.Log2(float64(len(pronounceableOnsets))) + math.Log2(float64(len(pronounceableNuclei)))
+
+	bits := float64(o.Syllables)*syllableBits + math.Log2(float64(len(pronounceableCodas)))
+	if o.Capitalize {
+		bits += math.Log2(float64(o.Syllables))
+	}
+	bits += float64(o.Digits) * math.Log2(10)
+
+	return bits
+}
+
+func (o PronounceableOptions) String() string {
+	extras := ""
+	if o.Capitalize {
+		extras += ", capitalised"
+	}
+	if o.Digits > 0 {
+		extras += fmt.Sprintf(", %d digits", o.Digits)
+	}
+	return fmt.Sprintf("%d syllables%s", o.Syllables, extras)
+}
+
+// draws every onset, nucleus, coda, capital and digit from the sampler
+func generatePronounceable(s *sampler, opts PronounceableOptions) string {
+	syllables := make([]string, opts.Syllables)
+	for i := range syllables {
+		onset := pronounceableOnsets[s.Intn(len(pronounceableOnsets))]
+		nucleus := pronounceableNuclei[s.Intn(len(pronounceableNuclei))]
+		syllables[i] = onset + nucleus
+	}
+
+	if opts.Capitalize {
+		position := s.Intn(len(syllables))
+		syllables[position] = capitalize(syllables[position])
+	}
+
+	var builder strings.Builder
+	for _, syllable := range syllables {
+		builder.WriteString(syllable)
+	}
+	builder.WriteString(pronounceableCodas[s.Intn(len(pronounceableCodas))])
+
+	for range opts.Digits {
+		builder.WriteByte(digitChars[s.Intn(len(digitChars))])
+	}
+
+	return builder.String()
+}
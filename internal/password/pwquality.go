@@ -0,0 +1,210 @@
+package password
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/nbutton23/zxcvbn-go"
+)
+
+// where libpwquality reads its settings on most distributions
+const PWQualityPath = "/etc/security/pwquality.conf"
+
+// the libpwquality rules datflux understands, see pwquality.conf(5)
+// credits > 0 let that many chars of a class count twice towards minlen,
+// credits < 0 require that many chars of the class
+type PWQuality struct {
+	MinLen         int
+	DCredit        int // digits
+	UCredit        int // uppercase
+	LCredit        int // lowercase
+	OCredit        int // other (symbols)
+	MinClass       int
+	MaxRepeat      int
+	MaxClassRepeat int
+	DictCheck      bool
+}
+
+// libpwquality's built-in defaults
+func DefaultPWQuality() PWQuality {
+	return PWQuality{
+		MinLen:    8,
+		DictCheck: true,
+	}
+}
+
+// libpwquality refuses anything shorter, whatever the config says
+const pwqualityMinLen = 6
+
+// reads pwquality.conf, keys datflux has no use for (retry, usercheck,
+// badwords...) are skipped so real system files load as they are
+func ParsePWQuality(r io.Reader) (PWQuality, error) {
+	quality := DefaultPWQuality()
+
+	sections, err := parseINI(r)
+	if err != nil {
+		return quality, err
+	}
+
+	targets := map[string]*int{
+		"minlen":         &quality.MinLen,
+		"dcredit":        &quality.DCredit,
+		"ucredit":        &quality.UCredit,
+		"lcredit":        &quality.LCredit,
+		"ocredit":        &quality.OCredit,
+		"minclass":       &quality.MinClass,
+		"maxrepeat":      &quality.MaxRepeat,
+		"maxclassrepeat": &quality.MaxClassRepeat,
+	}
+
+	for _, section := range sections {
+		for _, entry := range section.Entries {
+			target, known := targets[entry.Key]
+			if !known && entry.Key != "dictcheck" {
+				continue
+			}
+
+			value, err := strconv.Atoi(entry.Value)
+			if err != nil {
+				return quality, fmt.Errorf("line %d: %s: %q is not a number", entry.Line, entry.Key, entry.Value)
+			}
+
+			if known {
+				*target = value
+			} else {
+				quality.DictCheck = value != 0
+			}
+		}
+	}
+
+	quality.MinLen = max(quality.MinLen, pwqualityMinLen)
+	return quality, nil
+}
+
+func LoadPWQuality(path string) (PWQuality, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return PWQuality{}, err
+	}
+	defer file.Close()
+
+	quality, err := ParsePWQuality(file)
+	if err != nil {
+		return quality, fmt.Errorf("%s: %w", path, err)
+	}
+	return quality, nil
+}
+
+// tightens a policy until everything it generates passes these rules
+// minlen ignores credits, a full-length password passes with or without them
+func (q PWQuality) Apply(policy Policy) (Policy, error) {
+	if policy.MinLength < q.MinLen {
+		policy.MinLength = q.MinLen
+		policy.MaxLength = max(policy.MaxLength, q.MinLen)
+	}
+
+	if q.MaxRepeat > 0 && (policy.MaxRepeat == 0 || policy.MaxRepeat > q.MaxRepeat) {
+		policy.MaxRepeat = q.MaxRepeat
+	}
+	if q.MaxClassRepeat > 0 && (policy.MaxClassRepeat == 0 || policy.MaxClassRepeat > q.MaxClassRepeat) {
+		policy.MaxClassRepeat = q.MaxClassRepeat
+	}
+
+	policy.MinDigits = max(policy.MinDigits, -q.DCredit)
+	policy.MinUpper = max(policy.MinUpper, -q.UCredit)
+	policy.MinLower = max(policy.MinLower, -q.LCredit)
+	policy.MinSymbols = max(policy.MinSymbols, -q.OCredit)
+
+	if policy.ClassCount() < q.MinClass {
+		return policy, fmt.Errorf("%w: pwquality wants %d character classes, the policy has %d",
+			ErrImpossiblePolicy, q.MinClass, policy.ClassCount())
+	}
+
+	// strict class repeats reject most long draws, so shorten the range
+	space := policy.space()
+	for space.acceptance(policy.MaxLength) < minAcceptance && policy.MaxLength > policy.MinLength {
+		policy.MaxLength--
+	}
+
+	return policy, policy.Validate()
+}
+
+// every rule the password breaks, in pwquality's own wording where possible
+// dictcheck is approximated with zxcvbn's dictionaries, cracklib's are not used
+func (q PWQuality) Check(password string) []string {
+	var failures []string
+
+	counts := [4]int{}
+	for _, char := range password {
+		counts[classOf(char)]++
+	}
+
+	// credits: lower, upper, digit, other, same order as classOf
+	credits := [4]int{q.LCredit, q.UCredit, q.DCredit, q.OCredit}
+	names := [4]string{"lowercase letters", "uppercase letters", "digits", "other characters"}
+
+	size := len([]rune(password))
+	classes := 0
+	for i, count := range counts {
+		if count > 0 {
+			classes++
+		}
+
+		switch credit := credits[i]; {
+		case credit > 0:
+			size += min(count, credit)
+		case credit < 0 && count < -credit:
+			failures = append(failures, fmt.Sprintf("contains less than %d %s", -credit, names[i]))
+		}
+	}
+
+	if size < q.MinLen {
+		failures = append(failures, fmt.Sprintf("shorter than %d characters", q.MinLen))
+	}
+	if classes < q.MinClass {
+		failures = append(failures, fmt.Sprintf("contains less than %d character classes", q.MinClass))
+	}
+	if q.MaxRepeat > 0 && longestRun(password, identity) > q.MaxRepeat {
+		failures = append(failures, fmt.Sprintf("contains more than %d same characters consecutively", q.MaxRepeat))
+	}
+	if q.MaxClassRepeat > 0 && longestRun(password, classOf) > q.MaxClassRepeat {
+		failures = append(failures, fmt.Sprintf("contains more than %d characters of the same class consecutively", q.MaxClassRepeat))
+	}
+	if q.DictCheck && basedOnDictionaryWord(password) {
+		failures = append(failures, "is based on a dictionary word")
+	}
+
+	return failures
+}
+
+// a dictionary word covering at least half of the password,
+// roughly what cracklib means by "based on a dictionary word"
+func basedOnDictionaryWord(password string) bool {
+	for _, match := range zxcvbn.PasswordStrength(password, nil).MatchSequence {
+		if match.Pattern == "dictionary" && len(match.Token) >= 4 && 2*len(match.Token) >= len(password) {
+			return true
+		}
+	}
+	return false
+}
+
+func (q PWQuality) String() string {
+	description := fmt.Sprintf("minlen %d", q.MinLen)
+	for _, rule := range []struct {
+		name  string
+		value int
+	}{
+		{"dcredit", q.DCredit}, {"ucredit", q.UCredit}, {"lcredit", q.LCredit}, {"ocredit", q.OCredit},
+		{"minclass", q.MinClass}, {"maxrepeat", q.MaxRepeat}, {"maxclassrepeat", q.MaxClassRepeat},
+	} {
+		if rule.value != 0 {
+			description += fmt.Sprintf(", %s %d", rule.name, rule.value)
+		}
+	}
+	if q.DictCheck {
+		description += ", dictcheck"
+	}
+	return description
+}
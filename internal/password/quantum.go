@@ -0,0 +1,153 @@
+package password
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// a fault-tolerant quantum computer running Grover's search, which needs
+// about √N oracle calls where a classical search needs N, one after the
+// other: machines splitting the space only gain the square root of their
+// number
+type QuantumParams struct {
+	LogicalHz      float64 // logical gate layers per second
+	IterationDepth float64 // logical layers per Grover iteration, the password oracle plus diffusion
+	Machines       float64
+	TargetYears    float64 // how long a password should hold out
+}
+
+// deliberately generous to the attacker: a 100 MHz logical clock (error
+// corrected layers run far slower today), a 10^4 layer hash oracle and a
+// million machines
+func DefaultQuantumParams() QuantumParams {
+	return QuantumParams{LogicalHz: 1e8, IterationDepth: 1e4, Machines: 1e6, TargetYears: 100}
+}
+
+func (q QuantumParams) Validate() error {
+	switch {
+	case !(q.LogicalHz > 0) || math.IsInf(q.LogicalHz, 0):
+		return fmt.Errorf("logical_hz must be positive")
+	case !(q.IterationDepth >= 1) || math.IsInf(q.IterationDepth, 0):
+		return fmt.Errorf("iteration_depth must be at least 1")
+	case !(q.Machines >= 1) || math.IsInf(q.Machines, 0):
+		return fmt.Errorf("machines must be at least 1")
+	case !(q.TargetYears > 0) || math.IsInf(q.TargetYears, 0):
+		return fmt.Errorf("target_years must be positive")
+	}
+	return nil
+}
+
+func (q *QuantumParams) set(key, value string) error {
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return fmt.Errorf("%s: %q is not a number", key, value)
+	}
+
+	switch key {
+	case "logical_hz":
+		q.LogicalHz = number
+	case "iteration_depth":
+		q.IterationDepth = number
+	case "machines":
+		q.Machines = number
+	case "target_years":
+		q.TargetYears = number
+	default:
+		return fmt.Errorf("unknown quantum key %q (logical_hz, iteration_depth, machines, target_years)", key)
+	}
+	return nil
+}
+
+// Grover iterations one machine completes per second
+func (q QuantumParams) IterationsPerSec() float64 {
+	return q.LogicalHz / q.IterationDepth
+}
+
+// seconds until a 50% chance of finding the password: each machine
+// searches its share of the 2^bits space, and π/8·√share iterations put
+// the success probability at sin²(π/4) = 1/2
+func (q QuantumParams) Seconds(entropyBits float64) float64 {
+	share := math.Max(math.Pow(2, entropyBits)/q.Machines, 1)
+	return math.Pi / 8 * math.Sqrt(share) / q.IterationsPerSec()
+}
+
+// entropy that holds out for TargetYears, Seconds solved for the bits
+func (q QuantumParams) RequiredBits() float64 {
+	seconds := q.TargetYears * 365 * 24 * 3600
+	return 2*math.Log2(seconds*q.IterationsPerSec()*8/math.Pi) + math.Log2(q.Machines)
+}
+
+// e.g. "1e+04 Grover iterations/s on 1e+06 machines"
+func (q QuantumParams) String() string {
+	machines := "machines"
+	if q.Machines == 1 {
+		machines = "machine"
+	}
+	return fmt.Sprintf("%.3g Grover iterations/s on %.3g %s", q.IterationsPerSec(), q.Machines, machines)
+}
+
+// the shortest length the current settings reach the entropy at, and its
+// unit (characters, words, syllables); templates and regexes have a fixed
+// shape and no answer
+// character lengths ignore the constraints, which cost a few bits at most
+func (g *Generator) RecommendedLength(entropyBits float64) (int, string, bool) {
+	switch g.mode {
+	case ModePassphrase:
+		opts := g.passphrase
+		for words := MinPassphraseWords; words <= MaxPassphraseWords; words++ {
+			if opts.Words = words; opts.Entropy(g.wordlist) >= entropyBits {
+				return words, "words", true
+			}
+		}
+	case ModePronounceable:
+		opts := g.pronounceable
+		for syllables := MinPronounceableSyllables; syllables <= MaxPronounceableSyllables; syllables++ {
+			if opts.Syllables = syllables; opts.Entropy() >= entropyBits {
+				return syllables, "syllables", true
+			}
+		}
+	case ModeCharacters:
+		policy := g.activePolicy()
+		for length := 1; length <= MaxPolicyLength; length++ {
+			if policy.TheoreticalEntropy(length) >= entropyBits {
+				return length, "characters", true
+			}
+		}
+	}
+	return 0, "", false
+}
+
+// random characters needed for the entropy, drawn from the character
+// classes the password already uses, for passwords of unknown origin
+func RecommendedCharacters(password string, entropyBits float64) int {
+	var lower, upper, digits, symbols bool
+	for _, char := range password {
+		switch {
+		case unicode.IsLower(char):
+			lower = true
+		case unicode.IsUpper(char):
+			upper = true
+		case unicode.IsDigit(char):
+			digits = true
+		default:
+			symbols = true
+		}
+	}
+
+	size := 0
+	for _, class := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digits, 10}, {symbols, 32}} {
+		if class.used {
+			size += class.size
+		}
+	}
+	if size < 2 {
+		size = 26
+	}
+	return int(math.Ceil(entropyBits / math.Log2(float64(size))))
+}
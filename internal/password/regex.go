@@ -0,0 +1,467 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp/syntax"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var ErrUnsupportedRegex = errors.New("unsupported regular expression")
+
+const (
+	// refuse match spaces an attacker could enumerate
+	MinRegexEntropy = 40.0
+
+	// limits that keep compilation fast for hostile patterns
+	maxRegexNFAStates = 100000
+	maxRegexDFAStates = 20000
+
+	// generated strings use printable ASCII only
+	regexFirstChar = 0x20
+	regexLastChar  = 0x7e
+	regexAlphabet  = regexLastChar - regexFirstChar + 1
+)
+
+// set of printable ASCII chars, bit i is char regexFirstChar+i
+type charSet [2]uint64
+
+func (cs *charSet) add(char rune) {
+	if char < regexFirstChar || char > regexLastChar {
+		return
+	}
+	index := char - regexFirstChar
+	cs[index/64] |= 1 << (index % 64)
+}
+
+func (cs charSet) has(index int) bool {
+	return cs[index/64]&(1<<(index%64)) != 0
+}
+
+// Thompson NFA state, either a char edge or up to two epsilon edges
+type nfaState struct {
+	chars    charSet
+	hasChars bool
+	next     int   // target of the char edge
+	epsilon  []int // epsilon targets
+}
+
+type nfaBuilder struct {
+	states []nfaState
+}
+
+func (b *nfaBuilder) newState() (int, error) {
+	if len(b.states) >= maxRegexNFAStates {
+		return 0, fmt.Errorf("%w: pattern too complex", ErrUnsupportedRegex)
+	}
+	b.states = append(b.states, nfaState{next: -1})
+	return len(b.states) - 1, nil
+}
+
+// fragment with a single entry and a single exit state
+type nfaFragment struct {
+	start int
+	end   int
+}
+
+func (b *nfaBuilder) charFragment(chars charSet) (nfaFragment, error) {
+	start, err := b.newState()
+	if err != nil {
+		return nfaFragment{}, err
+	}
+	end, err := b.newState()
+	if err != nil {
+		return nfaFragment{}, err
+	}
+
+	b.states[start].chars = chars
+	b.states[start].hasChars = true
+	b.states[start].next = end
+	return nfaFragment{start, end}, nil
+}
+
+func (b *nfaBuilder) emptyFragment() (nfaFragment, error) {
+	state, err := b.newState()
+	return nfaFragment{state, state}, err
+}
+
+func (b *nfaBuilder) link(from, to int) {
+	b.states[from].epsilon = append(b.states[from].epsilon, to)
+}
+
+func (b *nfaBuilder) build(re *syntax.Regexp) (nfaFragment, error) {
+	switch re.Op {
+	case syntax.OpEmptyMatch:
+		return b.emptyFragment()
+
+	case syntax.OpNoMatch:
+		return b.charFragment(charSet{})
+
+	case syntax.OpLiteral:
+		fragment, err := b.emptyFragment()
+		if err != nil {
+			return fragment, err
+		}
+		for _, r := range re.Rune {
+			if r < regexFirstChar || r > regexLastChar {
+				return fragment, fmt.Errorf("%w: only printable ASCII literals are supported (got %q)",
+					ErrUnsupportedRegex, r)
+			}
+
+			var chars charSet
+			chars.add(r)
+			if re.Flags&syntax.FoldCase != 0 {
+				for folded := unicode.SimpleFold(r); folded != r; folded = unicode.SimpleFold(folded) {
+					chars.add(folded)
+				}
+			}
+
+			next, err := b.charFragment(chars)
+			if err != nil {
+				return fragment, err
+			}
+			b.link(fragment.end, next.start)
+			fragment.end = next.end
+		}
+		return fragment, nil
+
+	case syntax.OpCharClass:
+		var chars charSet
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			for r := max(re.Rune[i], regexFirstChar); r <= min(re.Rune[i+1], regexLastChar); r++ {
+				chars.add(r)
+			}
+		}
+		return b.charFragment(chars)
+
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		var chars charSet
+		for r := rune(regexFirstChar); r <= regexLastChar; r++ {
+			chars.add(r)
+		}
+		return b.charFragment(chars)
+
+	case syntax.OpCapture:
+		return b.build(re.Sub[0])
+
+	case syntax.OpConcat:
+		fragment, err := b.emptyFragment()
+		if err != nil {
+			return fragment, err
+		}
+		for _, sub := range re.Sub {
+			next, err := b.build(sub)
+			if err != nil {
+				return fragment, err
+			}
+			b.link(fragment.end, next.start)
+			fragment.end = next.end
+		}
+		return fragment, nil
+
+	case syntax.OpAlternate:
+		start, err := b.newState()
+		if err != nil {
+			return nfaFragment{}, err
+		}
+		end, err := b.newState()
+		if err != nil {
+			return nfaFragment{}, err
+		}
+		for _, sub := range re.Sub {
+			branch, err := b.build(sub)
+			if err != nil {
+				return nfaFragment{}, err
+			}
+			b.link(start, branch.start)
+			b.link(branch.end, end)
+		}
+		return nfaFragment{start, end}, nil
+
+	case syntax.OpQuest:
+		return b.repeat(re.Sub[0], 0, 1)
+
+	case syntax.OpRepeat:
+		if re.Max < 0 {
+			return nfaFragment{}, fmt.Errorf("%w: unbounded repeat {%d,}", ErrUnsupportedRegex, re.Min)
+		}
+		return b.repeat(re.Sub[0], re.Min, re.Max)
+
+	case syntax.OpStar, syntax.OpPlus:
+		return nfaFragment{}, fmt.Errorf("%w: unbounded %s, use an explicit {min,max}", ErrUnsupportedRegex, re)
+
+	case syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText:
+		return nfaFragment{}, fmt.Errorf("%w: anchors are only allowed at the start and end", ErrUnsupportedRegex)
+
+	default:
+		return nfaFragment{}, fmt.Errorf("%w: %s is not supported", ErrUnsupportedRegex, re)
+	}
+}
+
+// min mandatory copies followed by max-min optional ones
+func (b *nfaBuilder) repeat(sub *syntax.Regexp, minCount, maxCount int) (nfaFragment, error) {
+	fragment, err := b.emptyFragment()
+	if err != nil {
+		return fragment, err
+	}
+
+	var optionalEnds []int
+	for i := range maxCount {
+		next, err := b.build(sub)
+		if err != nil {
+			return fragment, err
+		}
+		b.link(fragment.end, next.start)
+		if i >= minCount {
+			optionalEnds = append(optionalEnds, fragment.end)
+		}
+		fragment.end = next.end
+	}
+
+	// every optional copy may be skipped straight to the end
+	for _, skipFrom := range optionalEnds {
+		b.link(skipFrom, fragment.end)
+	}
+
+	return fragment, nil
+}
+
+// strips ^/\A at the start and $/\z at the end, the output is the whole text
+func stripAnchors(re *syntax.Regexp) *syntax.Regexp {
+	isBegin := func(op syntax.Op) bool { return op == syntax.OpBeginText || op == syntax.OpBeginLine }
+	isEnd := func(op syntax.Op) bool { return op == syntax.OpEndText || op == syntax.OpEndLine }
+
+	switch {
+	case isBegin(re.Op) || isEnd(re.Op):
+		return &syntax.Regexp{Op: syntax.OpEmptyMatch}
+	case re.Op != syntax.OpConcat:
+		return re
+	}
+
+	subs := re.Sub
+	if len(subs) > 0 && isBegin(subs[0].Op) {
+		subs = subs[1:]
+	}
+	if len(subs) > 0 && isEnd(subs[len(subs)-1].Op) {
+		subs = subs[:len(subs)-1]
+	}
+
+	stripped := *re
+	stripped.Sub = subs
+	return &stripped
+}
+
+// generates uniformly over the strings a bounded regex matches
+// built as a DFA so strings with several derivations are not favoured
+type Regex struct {
+	Expr        string
+	transitions [][regexAlphabet]int32 // -1 for the dead state
+	accepting   []bool
+	counts      []*big.Int // matching strings reachable from each state
+}
+
+func ParseRegex(expr string) (*Regex, error) {
+	parsed, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedRegex, err)
+	}
+
+	builder := &nfaBuilder{}
+	fragment, err := builder.build(stripAnchors(parsed))
+	if err != nil {
+		return nil, err
+	}
+
+	regex := &Regex{Expr: expr}
+	if err := regex.determinize(builder.states, fragment); err != nil {
+		return nil, err
+	}
+	regex.count()
+
+	total := regex.Count()
+	switch {
+	case total.Sign() == 0:
+		return nil, fmt.Errorf("%w: %q matches no printable ASCII string", ErrUnsupportedRegex, expr)
+	case regex.Entropy() < MinRegexEntropy:
+		return nil, fmt.Errorf("%w: %q only matches %s strings (%.1f bits, need %.0f)",
+			ErrUnsupportedRegex, expr, total, regex.Entropy(), MinRegexEntropy)
+	}
+
+	return regex, nil
+}
+
+func epsilonClosure(states []nfaState, seeds []int) []int {
+	seen := make(map[int]bool, len(seeds))
+	stack := slices.Clone(seeds)
+	for _, seed := range seeds {
+		seen[seed] = true
+	}
+
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for _, next := range states[current].epsilon {
+			if !seen[next] {
+				seen[next] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+
+	closure := make([]int, 0, len(seen))
+	for state := range seen {
+		closure = append(closure, state)
+	}
+	slices.Sort(closure)
+	return closure
+}
+
+func stateSetKey(set []int) string {
+	var builder strings.Builder
+	for _, state := range set {
+		builder.WriteString(strconv.Itoa(state))
+		builder.WriteByte(',')
+	}
+	return builder.String()
+}
+
+// subset construction over the printable ASCII alphabet
+func (r *Regex) determinize(states []nfaState, fragment nfaFragment) error {
+	index := make(map[string]int32)
+	var sets [][]int
+
+	addSet := func(set []int) (int32, error) {
+		key := stateSetKey(set)
+		if id, ok := index[key]; ok {
+			return id, nil
+		}
+		if len(sets) >= maxRegexDFAStates {
+			return 0, fmt.Errorf("%w: pattern too complex", ErrUnsupportedRegex)
+		}
+
+		id := int32(len(sets))
+		index[key] = id
+		sets = append(sets, set)
+		r.accepting = append(r.accepting, slices.Contains(set, fragment.end))
+		r.transitions = append(r.transitions, [regexAlphabet]int32{})
+		return id, nil
+	}
+
+	if _, err := addSet(epsilonClosure(states, []int{fragment.start})); err != nil {
+		return err
+	}
+
+	for current := 0; current < len(sets); current++ {
+		for char := range regexAlphabet {
+			var targets []int
+			for _, state := range sets[current] {
+				if states[state].hasChars && states[state].chars.has(char) {
+					targets = append(targets, states[state].next)
+				}
+			}
+
+			if len(targets) == 0 {
+				r.transitions[current][char] = -1
+				continue
+			}
+
+			id, err := addSet(epsilonClosure(states, targets))
+			if err != nil {
+				return err
+			}
+			r.transitions[current][char] = id
+		}
+	}
+
+	return nil
+}
+
+// the DFA is acyclic (no unbounded repeats), so counts follow by
+// summing over transitions in reverse topological order
+func (r *Regex) count() {
+	r.counts = make([]*big.Int, len(r.transitions))
+
+	var visit func(state int32) *big.Int
+	visit = func(state int32) *big.Int {
+		if r.counts[state] != nil {
+			return r.counts[state]
+		}
+
+		total := new(big.Int)
+		if r.accepting[state] {
+			total.SetInt64(1)
+		}
+		for _, next := range r.transitions[state] {
+			if next >= 0 {
+				total.Add(total, visit(next))
+			}
+		}
+
+		r.counts[state] = total
+		return total
+	}
+
+	visit(0)
+}
+
+// number of distinct strings the pattern matches
+func (r *Regex) Count() *big.Int {
+	return new(big.Int).Set(r.counts[0])
+}
+
+// exact entropy, log2 of the match space
+func (r *Regex) Entropy() float64 {
+	return log2BigInt(r.counts[0])
+}
+
+func (r *Regex) String() string {
+	return r.Expr
+}
+
+func log2BigInt(n *big.Int) float64 {
+	if n.Sign() <= 0 {
+		return 0
+	}
+
+	// keep the top 64 bits for the mantissa
+	shift := max(n.BitLen()-64, 0)
+	top := new(big.Int).Rsh(n, uint(shift))
+	mantissa, _ := new(big.Float).SetInt(top).Float64()
+	return float64(shift) + math.Log2(mantissa)
+}
+
+// walks the DFA, stopping or picking each next char with probability
+// proportional to the number of matching strings behind it
+func generateFromRegex(s *sampler, regex *Regex) string {
+	var output []byte
+	state := int32(0)
+
+	for {
+		pick := s.BigIntn(regex.counts[state])
+
+		if regex.accepting[state] {
+			if pick.Sign() == 0 {
+				return string(output)
+			}
+			pick.Sub(pick, big.NewInt(1))
+		}
+
+		for char, next := range regex.transitions[state] {
+			if next < 0 {
+				continue
+			}
+			if pick.Cmp(regex.counts[next]) < 0 {
+				output = append(output, byte(char+regexFirstChar))
+				state = next
+				break
+			}
+			pick.Sub(pick, regex.counts[next])
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package password
+
+import (
+	"math/big"
+	"math/bits"
+
+	"datflux/internal/entropy"
+)
+
+// draws uniform integers straight from the collector's Fortuna byte stream
+// every index, length choice and shuffle swap consumes fresh entropy bytes
+type sampler struct {
+	refill func() []byte
+	buf    []byte
+}
+
+// standard mode, refilled 256 bits at a time
+func newSampler(collector *entropy.Collector) *sampler {
+	return &sampler{refill: collector.GetRawEntropy}
+}
+
+// paranoia mode, the given 512-bit block is consumed first and
+// every refill pulls another 512 bits
+func newParanoidSampler(collector *entropy.Collector, initial []byte) *sampler {
+	return &sampler{
+		refill: collector.GetRawEntropy512,
+		buf:    initial,
+	}
+}
+
+func (s *sampler) nextByte() byte {
+	for len(s.buf) == 0 {
+		s.buf = s.refill()
+	}
+
+	b := s.buf[0]
+	s.buf = s.buf[1:]
+	return b
+}
+
+// uniform integer in [0, n)
+func (s *sampler) Intn(n int) int {
+	if n <= 1 {
+		return 0
+	}
+	return int(s.Uint64n(uint64(n)))
+}
+
+// uniform integer in [0, n) for bounds past int on 32-bit targets
+// rejection sampling on a bit mask, so there is no modulo bias
+func (s *sampler) Uint64n(n uint64) uint64 {
+	if n <= 1 {
+		return 0
+	}
+
+	limit := n - 1
+	width := bits.Len64(limit)
+	mask := uint64(1)<<width - 1
+	if width == 64 {
+		mask = ^uint64(0)
+	}
+	numBytes := (width + 7) / 8
+
+	for {
+		var v uint64
+		for range numBytes {
+			v = v<<8 | uint64(s.nextByte())
+		}
+		v &= mask
+
+		if v <= limit {
+			return v
+		}
+	}
+}
+
+// uniform float in [0, 1) with 53 random bits
+func (s *sampler) Float64() float64 {
+	return float64(s.Uint64n(1<<53)) / (1 << 53)
+}
+
+// uniform big integer in [0, n), same masked rejection as Intn
+func (s *sampler) BigIntn(n *big.Int) *big.Int {
+	limit := new(big.Int).Sub(n, big.NewInt(1))
+	if limit.Sign() <= 0 {
+		return new(big.Int)
+	}
+
+	width := limit.BitLen()
+	buf := make([]byte, (width+7)/8)
+	excessBits := uint(len(buf)*8 - width)
+
+	v := new(big.Int)
+	for {
+		for i := range buf {
+			buf[i] = s.nextByte()
+		}
+		buf[0] &= 0xff >> excessBits
+
+		v.SetBytes(buf)
+		if v.Cmp(limit) <= 0 {
+			return v
+		}
+	}
+}
+
+// in-place Fisher-Yates shuffle
+func (s *sampler) Shuffle(b []byte) {
+	for i := len(b) - 1; i > 0; i-- {
+		j := s.Intn(i + 1)
+		b[i], b[j] = b[j], b[i]
+	}
+}
@@ -0,0 +1,105 @@
+package password
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"datflux/internal/entropy"
+)
+
+// a collector whose seed file lives in a temporary config dir
+func newTestCollector(t *testing.T) *entropy.Collector {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	collector := entropy.NewCollector(time.Millisecond, 10)
+	t.Cleanup(collector.Close)
+	return collector
+}
+
+// chi-square critical value at p = 1e-4 by the Wilson-Hilferty
+// approximation, strict enough that an unbiased sampler never trips it
+// in practice while modulo bias does at these sample sizes
+func chiSquareCritical(df int) float64 {
+	const z = 3.719
+	k := float64(df)
+	return k * math.Pow(1-2/(9*k)+z*math.Sqrt(2/(9*k)), 3)
+}
+
+func assertUniform(t *testing.T, name string, counts []int) {
+	t.Helper()
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	expected := float64(total) / float64(len(counts))
+
+	chiSquare := 0.0
+	for _, count := range counts {
+		diff := float64(count) - expected
+		chiSquare += diff * diff / expected
+	}
+	if critical := chiSquareCritical(len(counts) - 1); chiSquare > critical {
+		t.Errorf("%s: chi-square %.1f exceeds %.1f for %d buckets, the distribution is biased",
+			name, chiSquare, critical, len(counts))
+	}
+}
+
+// bounds that aren't powers of two go through the rejection path, a
+// modulo reduction would overweight the low values
+func TestIntnUniform(t *testing.T) {
+	s := newSampler(newTestCollector(t))
+	for _, n := range []int{3, 10, 62, 200, 1000} {
+		counts := make([]int, n)
+		for range 200 * n {
+			v := s.Intn(n)
+			if v < 0 || v >= n {
+				t.Fatalf("Intn(%d) returned %d", n, v)
+			}
+			counts[v]++
+		}
+		assertUniform(t, "Intn", counts)
+	}
+}
+
+func TestBigIntnUniform(t *testing.T) {
+	s := newSampler(newTestCollector(t))
+	for _, n := range []int64{3, 10, 94, 1000} {
+		bound := big.NewInt(n)
+		counts := make([]int, n)
+		for range 200 * n {
+			v := s.BigIntn(bound)
+			if v.Sign() < 0 || v.Cmp(bound) >= 0 {
+				t.Fatalf("BigIntn(%d) returned %s", n, v)
+			}
+			counts[v.Int64()]++
+		}
+		assertUniform(t, "BigIntn", counts)
+	}
+}
+
+// each class's minimum skews how often classes appear, but within a class
+// every character must be equally likely
+func TestGeneratedCharactersUniform(t *testing.T) {
+	generator := NewGenerator(newTestCollector(t))
+
+	counts := map[byte]int{}
+	for range 4000 {
+		password, err := generator.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range len(password) {
+			counts[password[i]]++
+		}
+	}
+
+	for _, class := range generator.GetPolicy().Classes() {
+		classCounts := make([]int, len(class.Chars))
+		for i := range len(class.Chars) {
+			classCounts[i] = counts[class.Chars[i]]
+		}
+		assertUniform(t, class.Name, classCounts)
+	}
+}
@@ -0,0 +1,165 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidTemplate = errors.New("invalid template")
+
+const (
+	vowelChars     = "aeiou"
+	consonantChars = "bcdfghjklmnpqrstvwxyz"
+)
+
+// template tokens, anything else is copied literally
+//
+//	a / A   lowercase / uppercase letter
+//	c / C   lowercase / uppercase consonant
+//	v / V   lowercase / uppercase vowel
+//	x / X   lowercase / uppercase letter or digit
+//	h / H   lowercase / uppercase hex digit
+//	9       digit
+//	!       symbol
+//	*       any letter, digit or symbol
+//	[...]   one of the listed characters
+//	\t      literal t (escapes any token)
+//	{n}     repeats the previous token or literal n times
+var templateClasses = map[byte]string{
+	'a': lowerChars,
+	'A': upperChars,
+	'c': consonantChars,
+	'C': strings.ToUpper(consonantChars),
+	'v': vowelChars,
+	'V': strings.ToUpper(vowelChars),
+	'x': lowerChars + digitChars,
+	'X': upperChars + digitChars,
+	'h': "0123456789abcdef",
+	'H': "0123456789ABCDEF",
+	'9': digitChars,
+	'!': symbolChars,
+	'*': lowerChars + upperChars + digitChars + symbolChars,
+}
+
+// one output position, either a fixed literal or a class to draw from
+type templateToken struct {
+	literal byte
+	chars   string
+}
+
+type Template struct {
+	Pattern string
+	tokens  []templateToken
+}
+
+// parses and validates a pattern such as "Cvcc-9999-!!" or "X{4}-X{4}-X{4}"
+func ParseTemplate(pattern string) (*Template, error) {
+	var tokens []templateToken
+
+	for i := 0; i < len(pattern); i++ {
+		char := pattern[i]
+
+		switch {
+		case char == '\\':
+			if i+1 >= len(pattern) {
+				return nil, fmt.Errorf("%w: dangling escape at end of %q", ErrInvalidTemplate, pattern)
+			}
+			i++
+			tokens = append(tokens, templateToken{literal: pattern[i]})
+
+		case char == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated [ at position %d", ErrInvalidTemplate, i)
+			}
+			chars := uniqueASCII(pattern[i+1 : i+1+end])
+			if chars == "" {
+				return nil, fmt.Errorf("%w: empty character set at position %d", ErrInvalidTemplate, i)
+			}
+			tokens = append(tokens, templateToken{chars: chars})
+			i += end + 1
+
+		case char == '{':
+			end := strings.IndexByte(pattern[i+1:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated { at position %d", ErrInvalidTemplate, i)
+			}
+			if len(tokens) == 0 {
+				return nil, fmt.Errorf("%w: repeat count with nothing to repeat", ErrInvalidTemplate)
+			}
+			count, err := strconv.Atoi(pattern[i+1 : i+1+end])
+			if err != nil || count < 1 {
+				return nil, fmt.Errorf("%w: bad repeat count {%s}", ErrInvalidTemplate, pattern[i+1:i+1+end])
+			}
+			if len(tokens)+count-1 > MaxPolicyLength {
+				return nil, fmt.Errorf("%w: longer than %d chars", ErrInvalidTemplate, MaxPolicyLength)
+			}
+			last := tokens[len(tokens)-1]
+			for range count - 1 {
+				tokens = append(tokens, last)
+			}
+			i += end + 1
+
+		case char == ']' || char == '}':
+			return nil, fmt.Errorf("%w: unbalanced %c at position %d", ErrInvalidTemplate, char, i)
+
+		case char < 0x20 || char > 0x7e:
+			return nil, fmt.Errorf("%w: only printable ASCII is supported", ErrInvalidTemplate)
+
+		default:
+			if chars, ok := templateClasses[char]; ok {
+				tokens = append(tokens, templateToken{chars: chars})
+			} else {
+				tokens = append(tokens, templateToken{literal: char})
+			}
+		}
+	}
+
+	template := &Template{Pattern: pattern, tokens: tokens}
+
+	switch {
+	case len(tokens) == 0:
+		return nil, fmt.Errorf("%w: empty template", ErrInvalidTemplate)
+	case len(tokens) > MaxPolicyLength:
+		return nil, fmt.Errorf("%w: longer than %d chars", ErrInvalidTemplate, MaxPolicyLength)
+	case template.Entropy() == 0:
+		return nil, fmt.Errorf("%w: %q has no random positions", ErrInvalidTemplate, pattern)
+	}
+
+	return template, nil
+}
+
+// output length, every token is one character
+func (t *Template) Length() int {
+	return len(t.tokens)
+}
+
+// exact entropy, every position is drawn independently and uniformly
+func (t *Template) Entropy() float64 {
+	bits := 0.0
+	for _, token := range t.tokens {
+		if token.chars != "" {
+			bits += math.Log2(float64(len(token.chars)))
+		}
+	}
+	return bits
+}
+
+func (t *Template) String() string {
+	return t.Pattern
+}
+
+func generateFromTemplate(s *sampler, template *Template) string {
+	output := make([]byte, len(template.tokens))
+	for i, token := range template.tokens {
+		if token.chars == "" {
+			output[i] = token.literal
+		} else {
+			output[i] = token.chars[s.Intn(len(token.chars))]
+		}
+	}
+	return string(output)
+}
@@ -0,0 +1,184 @@
+package password
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"datflux/internal/entropy"
+)
+
+// how a token's random bytes are written out
+type TokenFormat string
+
+const (
+	TokenHex       TokenFormat = "hex"
+	TokenBase64    TokenFormat = "base64"    // unpadded
+	TokenBase64URL TokenFormat = "base64url" // unpadded
+	TokenBase32    TokenFormat = "base32"    // unpadded, RFC 4648 alphabet
+	TokenUUID      TokenFormat = "uuid"      // version 4, 122 random bits
+	TokenULID      TokenFormat = "ulid"      // millisecond time plus 80 random bits
+)
+
+var TokenFormats = []TokenFormat{TokenHex, TokenBase64, TokenBase64URL, TokenBase32, TokenUUID, TokenULID}
+
+// below 128 bits a token is guessable, above 1024 bytes it's a file
+const (
+	MinTokenBytes     = 16
+	MaxTokenBytes     = 1024
+	DefaultTokenBytes = 32
+	maxTokenPrefix    = 32
+)
+
+var tokenPrefixPattern = regexp.MustCompile(`^[A-Za-z0-9_-]*$`)
+
+// API keys, HMAC secrets, cookie keys and the like, as opposed to
+// passwords people type
+// the checksum is the CRC32 of prefix and body, appended in the token's
+// own encoding like GitHub's tokens, so scanners can tell a real leaked
+// token from random noise without asking the issuer
+type TokenOptions struct {
+	Bytes    int // random bytes for the encodings, 0 means DefaultTokenBytes
+	Format   TokenFormat
+	Prefix   string // e.g. "dfx_live_"
+	Checksum bool
+}
+
+func DefaultTokenOptions() TokenOptions {
+	return TokenOptions{Bytes: DefaultTokenBytes, Format: TokenHex}
+}
+
+func (o TokenOptions) fixedSize() bool {
+	return o.Format == TokenUUID || o.Format == TokenULID
+}
+
+func (o TokenOptions) Validate() error {
+	if !slices.Contains(TokenFormats, o.Format) {
+		return fmt.Errorf("unknown token format %q (hex, base64, base64url, base32, uuid, ulid)", o.Format)
+	}
+	if o.fixedSize() {
+		if o.Bytes != 0 {
+			return fmt.Errorf("%s tokens have a fixed size, drop --bytes", o.Format)
+		}
+		if o.Checksum {
+			return fmt.Errorf("%s tokens have a fixed format, a checksum would break it", o.Format)
+		}
+	} else if o.Bytes != 0 && (o.Bytes < MinTokenBytes || o.Bytes > MaxTokenBytes) {
+		return fmt.Errorf("tokens need %d-%d random bytes (got %d)", MinTokenBytes, MaxTokenBytes, o.Bytes)
+	}
+
+	if len(o.Prefix) > maxTokenPrefix || !tokenPrefixPattern.MatchString(o.Prefix) {
+		return fmt.Errorf("prefix %q must be up to %d letters, digits, _ or -", o.Prefix, maxTokenPrefix)
+	}
+	return nil
+}
+
+func (o TokenOptions) randomBytes() int {
+	switch {
+	case o.Format == TokenUUID:
+		return 16
+	case o.Format == TokenULID:
+		return 10
+	case o.Bytes == 0:
+		return DefaultTokenBytes
+	}
+	return o.Bytes
+}
+
+// random bits in the token, uuid loses 6 to the version and variant
+func (o TokenOptions) Entropy() float64 {
+	if o.Format == TokenUUID {
+		return 122
+	}
+	return float64(8 * o.randomBytes())
+}
+
+// e.g. "32 bytes, base64url, prefix "dfx_live_", CRC32 checksum"
+func (o TokenOptions) String() string {
+	text := string(o.Format)
+	if !o.fixedSize() {
+		text = fmt.Sprintf("%d bytes, %s", o.randomBytes(), o.Format)
+	}
+	if o.Prefix != "" {
+		text += fmt.Sprintf(", prefix %q", o.Prefix)
+	}
+	if o.Checksum {
+		text += ", CRC32 checksum"
+	}
+	return text
+}
+
+// one token from fresh collector entropy, 256 bits per draw or 512 for
+// tokens longer than that
+func GenerateToken(collector *entropy.Collector, opts TokenOptions) (string, error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+
+	s := newSampler(collector)
+	if opts.randomBytes() > 32 {
+		s = newParanoidSampler(collector, nil)
+	}
+	random := make([]byte, opts.randomBytes())
+	for i := range random {
+		random[i] = s.nextByte()
+	}
+
+	token := opts.Prefix + encodeToken(opts.Format, random)
+	if opts.Checksum {
+		var sum [4]byte
+		binary.BigEndian.PutUint32(sum[:], crc32.ChecksumIEEE([]byte(token)))
+		token += encodeToken(opts.Format, sum[:])
+	}
+	return token, nil
+}
+
+func encodeToken(format TokenFormat, data []byte) string {
+	switch format {
+	case TokenBase64:
+		return base64.RawStdEncoding.EncodeToString(data)
+	case TokenBase64URL:
+		return base64.RawURLEncoding.EncodeToString(data)
+	case TokenBase32:
+		return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(data)
+	case TokenUUID:
+		data[6] = data[6]&0x0f | 0x40 // version 4
+		data[8] = data[8]&0x3f | 0x80 // RFC 4122 variant
+		text := hex.EncodeToString(data)
+		return strings.Join([]string{text[:8], text[8:12], text[12:16], text[16:20], text[20:]}, "-")
+	case TokenULID:
+		return encodeULID(uint64(time.Now().UnixMilli()), data)
+	}
+	return hex.EncodeToString(data)
+}
+
+// Crockford's base32, no I, L, O or U
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// 48-bit big-endian milliseconds then the 80 random bits, 26 characters
+// that sort by creation time
+func encodeULID(milliseconds uint64, random []byte) string {
+	var raw [16]byte
+	for i := range 6 {
+		raw[i] = byte(milliseconds >> (40 - 8*i))
+	}
+	copy(raw[6:], random)
+
+	// 128 bits in 26 characters, the first one carries only 3
+	text := make([]byte, 26)
+	high := binary.BigEndian.Uint64(raw[:8])
+	low := binary.BigEndian.Uint64(raw[8:])
+	for i := 25; i >= 0; i-- {
+		text[i] = crockfordAlphabet[low&31]
+		low = low>>5 | high<<59
+		high >>= 5
+	}
+	return string(text)
+}
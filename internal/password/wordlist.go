@@ -0,0 +1,124 @@
+package password
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"datflux/internal/entropy"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// smaller lists make every word worth less than 10 bits
+const MinWordlistSize = 1024
+
+var ErrWordlistTooSmall = errors.New("wordlist too small")
+
+// user wordlists live in <config dir>/wordlists/<name>.txt
+const wordlistExt = ".txt"
+
+func WordlistDir() string {
+	return filepath.Join(entropy.ConfigDir(), "wordlists")
+}
+
+// one word per line, optionally prefixed with dice rolls like the EFF lists
+// words are NFC-normalised and lower-cased, duplicates are dropped
+// blank lines and lines starting with # are ignored
+func ParseWordlist(name string, r io.Reader) (*Wordlist, error) {
+	seen := make(map[string]bool)
+	var words []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		word := normalizeWord(fields[len(fields)-1])
+		if word == "" || seen[word] {
+			continue
+		}
+
+		seen[word] = true
+		words = append(words, word)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading wordlist %q: %w", name, err)
+	}
+
+	if len(words) < MinWordlistSize {
+		return nil, fmt.Errorf("%w: %q has %d unique words, need at least %d",
+			ErrWordlistTooSmall, name, len(words), MinWordlistSize)
+	}
+
+	return &Wordlist{Name: name, Words: words}, nil
+}
+
+func normalizeWord(word string) string {
+	word = norm.NFC.String(strings.ToLower(word))
+
+	// words must stay readable and typeable, and letters only since
+	// separators aren't: "drop-down" joined with "-" reads as two words
+	for _, char := range word {
+		if !unicode.IsLetter(char) && !unicode.IsMark(char) {
+			return ""
+		}
+	}
+
+	return word
+}
+
+func LoadWordlist(path string) (*Wordlist, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return ParseWordlist(name, file)
+}
+
+// names of the embedded list plus every list in WordlistDir
+func AvailableWordlists() []string {
+	names := []string{EFFLargeWordlistName}
+
+	matches, _ := filepath.Glob(filepath.Join(WordlistDir(), "*"+wordlistExt))
+	for _, match := range matches {
+		name := strings.TrimSuffix(filepath.Base(match), wordlistExt)
+		if name != EFFLargeWordlistName {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names[1:])
+	return names
+}
+
+// the embedded EFF list or a list from WordlistDir, by name
+func FindWordlist(name string) (*Wordlist, error) {
+	if name == "" || name == EFFLargeWordlistName {
+		return EFFLargeWordlist(), nil
+	}
+
+	if strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid wordlist name %q", name)
+	}
+
+	path := filepath.Join(WordlistDir(), name+wordlistExt)
+	wordlist, err := LoadWordlist(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("wordlist %q not found in %s", name, WordlistDir())
+	}
+	return wordlist, err
+}
@@ -0,0 +1,135 @@
+package qr
+
+// the eight data masks, true where a data module gets flipped
+var masks = [8]func(x, y int) bool{
+	func(x, y int) bool { return (x+y)%2 == 0 },
+	func(x, y int) bool { return y%2 == 0 },
+	func(x, y int) bool { return x%3 == 0 },
+	func(x, y int) bool { return (x+y)%3 == 0 },
+	func(x, y int) bool { return (x/3+y/2)%2 == 0 },
+	func(x, y int) bool { return x*y%2+x*y%3 == 0 },
+	func(x, y int) bool { return (x*y%2+x*y%3)%2 == 0 },
+	func(x, y int) bool { return ((x+y)%2+x*y%3)%2 == 0 },
+}
+
+// xor is its own inverse, so applying a mask twice undoes it
+func (c *Code) applyMask(mask int) {
+	for y := range c.Size {
+		for x := range c.Size {
+			if !c.function[y][x] && masks[mask](x, y) {
+				c.Modules[y][x] = !c.Modules[y][x]
+			}
+		}
+	}
+}
+
+// tries every mask and keeps the one with the lowest penalty
+func (c *Code) applyBestMask() {
+	best, lowest := 0, -1
+	for mask := range masks {
+		c.applyMask(mask)
+		c.drawFormat(mask)
+		if penalty := c.penalty(); lowest < 0 || penalty < lowest {
+			best, lowest = mask, penalty
+		}
+		c.applyMask(mask)
+	}
+	c.applyMask(best)
+	c.drawFormat(best)
+}
+
+const (
+	penaltyRun     = 3
+	penaltyBox     = 3
+	penaltyFinder  = 40
+	penaltyBalance = 10
+)
+
+// the four rules from ISO/IEC 18004 7.8.3: long runs, 2x2 boxes,
+// finder-like patterns and the dark/light balance
+func (c *Code) penalty() int {
+	result := 0
+	for i := range c.Size {
+		result += c.linePenalty(func(j int) bool { return c.Modules[i][j] })
+		result += c.linePenalty(func(j int) bool { return c.Modules[j][i] })
+	}
+
+	dark := 0
+	for y := range c.Size {
+		for x := range c.Size {
+			if c.Modules[y][x] {
+				dark++
+			}
+			if x > 0 && y > 0 {
+				color := c.Modules[y][x]
+				if color == c.Modules[y][x-1] && color == c.Modules[y-1][x] && color == c.Modules[y-1][x-1] {
+					result += penaltyBox
+				}
+			}
+		}
+	}
+
+	// 10 points for every full 5% away from half dark
+	total := c.Size * c.Size
+	steps := (abs(dark*20-total*10)+total-1)/total - 1
+	return result + max(steps, 0)*penaltyBalance
+}
+
+// runs of five or more, and 1:1:3:1:1 dark patterns with four light
+// modules on either side, the quiet zone counting as light
+func (c *Code) linePenalty(module func(int) bool) int {
+	result := 0
+	line := make([]bool, 0, c.Size+8)
+	for range 4 {
+		line = append(line, false)
+	}
+	for j := range c.Size {
+		line = append(line, module(j))
+	}
+	for range 4 {
+		line = append(line, false)
+	}
+
+	run := 1
+	for j := 1; j <= c.Size; j++ {
+		if j < c.Size && module(j) == module(j-1) {
+			run++
+			continue
+		}
+		if run >= 5 {
+			result += penaltyRun + run - 5
+		}
+		run = 1
+	}
+
+	finder := []bool{true, false, true, true, true, false, true}
+	for j := 0; j+7 <= len(line); j++ {
+		if !matches(line[j:j+7], finder) {
+			continue
+		}
+		before := j >= 4 && !anyDark(line[j-4:j])
+		after := j+11 <= len(line) && !anyDark(line[j+7:j+11])
+		if before || after {
+			result += penaltyFinder
+		}
+	}
+	return result
+}
+
+func matches(line, pattern []bool) bool {
+	for i := range pattern {
+		if line[i] != pattern[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func anyDark(modules []bool) bool {
+	for _, dark := range modules {
+		if dark {
+			return true
+		}
+	}
+	return false
+}
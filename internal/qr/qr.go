@@ -0,0 +1,260 @@
+package qr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// returned when the data doesn't fit a version 40 symbol at the level
+var ErrTooLong = errors.New("data too long for a QR code")
+
+// error correction level, how much of the symbol can be damaged
+type Level int
+
+const (
+	Low      Level = iota // ~7%
+	Medium                // ~15%
+	Quartile              // ~25%
+	High                  // ~30%
+)
+
+// the two bits the format information stores for each level
+var levelFormatBits = [...]int{Low: 1, Medium: 0, Quartile: 3, High: 2}
+
+// a QR code symbol in byte mode, Modules[y][x] is true for dark modules
+// the quiet zone around it is left to the renderer
+type Code struct {
+	Version int
+	Level   Level
+	Size    int
+	Modules [][]bool
+
+	function [][]bool // finder, timing, alignment and format modules
+}
+
+// the smallest symbol holding data in byte mode at the level
+func Encode(data []byte, level Level) (*Code, error) {
+	version := 0
+	for v := 1; v <= 40; v++ {
+		if bitsNeeded(len(data), v) <= 8*dataCodewords(v, level) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("%w: %d bytes", ErrTooLong, len(data))
+	}
+
+	code := newCode(version, level)
+	code.drawFunctionPatterns()
+	code.drawCodewords(interleave(encodeData(data, version, level), version, level))
+	code.applyBestMask()
+	return code, nil
+}
+
+func newCode(version int, level Level) *Code {
+	size := 4*version + 17
+	code := &Code{Version: version, Level: level, Size: size}
+	code.Modules = make([][]bool, size)
+	code.function = make([][]bool, size)
+	for y := range size {
+		code.Modules[y] = make([]bool, size)
+		code.function[y] = make([]bool, size)
+	}
+	return code
+}
+
+// byte mode: mode indicator, count, data, terminator and padding
+func bitsNeeded(length, version int) int {
+	return 4 + countBits(version) + 8*length
+}
+
+func countBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, (value>>i)&1 == 1)
+	}
+}
+
+func encodeData(data []byte, version int, level Level) []byte {
+	capacity := 8 * dataCodewords(version, level)
+
+	var bits bitBuffer
+	bits.append(0b0100, 4)
+	bits.append(len(data), countBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	codewords := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			codewords[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return codewords
+}
+
+func (c *Code) setFunction(x, y int, dark bool) {
+	c.Modules[y][x] = dark
+	c.function[y][x] = true
+}
+
+func (c *Code) drawFunctionPatterns() {
+	for i := range c.Size {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	c.drawFinder(3, 3)
+	c.drawFinder(c.Size-4, 3)
+	c.drawFinder(3, c.Size-4)
+
+	positions := alignmentPositions(c.Version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// the finders' corners
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			c.drawAlignment(x, y)
+		}
+	}
+
+	// reserved now, written once the mask is known
+	c.drawFormat(0)
+	c.drawVersion()
+}
+
+// 7x7 finder plus its light separator, clipped at the edges
+func (c *Code) drawFinder(centerX, centerY int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := centerX+dx, centerY+dy
+			if x < 0 || x >= c.Size || y < 0 || y >= c.Size {
+				continue
+			}
+			distance := max(abs(dx), abs(dy))
+			c.setFunction(x, y, distance != 2 && distance != 4)
+		}
+	}
+}
+
+func (c *Code) drawAlignment(centerX, centerY int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunction(centerX+dx, centerY+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+
+	count := version/7 + 2
+	step := (version*8 + count*3 + 5) / (count*4 - 4) * 2
+	positions := make([]int, count)
+	positions[0] = 6
+	for i := count - 1; i >= 1; i-- {
+		positions[i] = 4*version + 17 - 7 - (count-1-i)*step
+	}
+	return positions
+}
+
+// level and mask with a BCH(15,5) code, twice, plus the dark module
+func (c *Code) drawFormat(mask int) {
+	data := levelFormatBits[c.Level]<<3 | mask
+	remainder := data
+	for range 10 {
+		remainder = remainder<<1 ^ (remainder>>9)*0x537
+	}
+	bits := (data<<10 | remainder) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	for i := range 6 {
+		c.setFunction(8, i, bit(i))
+	}
+	c.setFunction(8, 7, bit(6))
+	c.setFunction(8, 8, bit(7))
+	c.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := range 8 {
+		c.setFunction(c.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.Size-15+i, bit(i))
+	}
+	c.setFunction(8, c.Size-8, true)
+}
+
+// version 7 and up carry their version with a BCH(18,6) code, twice
+func (c *Code) drawVersion() {
+	if c.Version < 7 {
+		return
+	}
+
+	remainder := c.Version
+	for range 12 {
+		remainder = remainder<<1 ^ (remainder>>11)*0x1F25
+	}
+	bits := c.Version<<12 | remainder
+
+	for i := range 18 {
+		dark := (bits>>i)&1 == 1
+		a, b := c.Size-11+i%3, i/3
+		c.setFunction(a, b, dark)
+		c.setFunction(b, a, dark)
+	}
+}
+
+// the zigzag of two-module columns from the bottom right, skipping the
+// vertical timing pattern
+func (c *Code) drawCodewords(codewords []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vertical := range c.Size {
+			y := vertical
+			if upward {
+				y = c.Size - 1 - vertical
+			}
+			for j := range 2 {
+				x := right - j
+				if c.function[y][x] || i >= 8*len(codewords) {
+					continue
+				}
+				c.Modules[y][x] = (codewords[i/8]>>(7-i%8))&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,46 @@
+package qr
+
+import "strings"
+
+// the quiet zone ISO/IEC 18004 asks for, scanners often cope with less
+const QuietZone = 4
+
+// the symbol as text, two module rows per line using half blocks so the
+// modules come out square; glyphs draw the dark modules, so the caller
+// colors them dark on a light background
+// scale repeats each module across and down
+func (c *Code) HalfBlocks(quiet, scale int) []string {
+	scale = max(scale, 1)
+	width := (c.Size + 2*quiet) * scale
+
+	dark := func(x, y int) bool {
+		x, y = x/scale-quiet, y/scale-quiet
+		return x >= 0 && x < c.Size && y >= 0 && y < c.Size && c.Modules[y][x]
+	}
+
+	lines := make([]string, 0, (width+1)/2)
+	for y := 0; y < width; y += 2 {
+		var line strings.Builder
+		for x := range width {
+			top, bottom := dark(x, y), y+1 < width && dark(x, y+1)
+			switch {
+			case top && bottom:
+				line.WriteString("█")
+			case top:
+				line.WriteString("▀")
+			case bottom:
+				line.WriteString("▄")
+			default:
+				line.WriteString(" ")
+			}
+		}
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// columns and lines HalfBlocks needs
+func (c *Code) RenderedSize(quiet, scale int) (int, int) {
+	width := (c.Size + 2*quiet) * max(scale, 1)
+	return width, (width + 1) / 2
+}
@@ -0,0 +1,43 @@
+package qr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// the characters ZXing's WIFI: format needs escaped
+var wifiEscaper = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, `:`, `\:`, `"`, `\"`)
+
+// 802.11 SSIDs are 1-32 bytes
+func ValidateSSID(ssid string) error {
+	if ssid == "" || len(ssid) > 32 {
+		return fmt.Errorf("SSIDs are 1-32 bytes (got %d)", len(ssid))
+	}
+	return nil
+}
+
+// WPA passphrases are 8-63 printable ASCII characters, anything else is
+// refused by the network
+func ValidateWPAPassphrase(passphrase string) error {
+	if len(passphrase) < 8 || len(passphrase) > 63 {
+		return fmt.Errorf("WPA passphrases are 8-63 characters (got %d)", len(passphrase))
+	}
+	for _, char := range passphrase {
+		if char < ' ' || char > '~' {
+			return fmt.Errorf("WPA passphrases are printable ASCII only")
+		}
+	}
+	return nil
+}
+
+// the payload phone cameras offer to join a network from, e.g.
+// WIFI:T:WPA;S:home;P:secret;;
+func WiFi(ssid, passphrase string) (string, error) {
+	if err := ValidateSSID(ssid); err != nil {
+		return "", err
+	}
+	if err := ValidateWPAPassphrase(passphrase); err != nil {
+		return "", err
+	}
+	return "WIFI:T:WPA;S:" + wifiEscaper.Replace(ssid) + ";P:" + wifiEscaper.Replace(passphrase) + ";;", nil
+}
@@ -0,0 +1,159 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" // nosec G505 -- RFC 6238's default, the one every authenticator app supports
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"datflux/internal/entropy"
+)
+
+// RFC 6238 time-based one-time passwords, the secret comes from the
+// collector like the passwords do
+type Algorithm string
+
+const (
+	SHA1   Algorithm = "SHA1"
+	SHA256 Algorithm = "SHA256"
+	SHA512 Algorithm = "SHA512"
+)
+
+var Algorithms = []Algorithm{SHA1, SHA256, SHA512}
+
+// RFC 4226 asks for at least 128 bits and recommends 160
+const (
+	MinSecretBytes     = 16
+	MaxSecretBytes     = 64
+	DefaultSecretBytes = 20
+)
+
+type Options struct {
+	Issuer    string // service name the authenticator app shows
+	Account   string
+	Digits    int
+	Period    int // seconds
+	Algorithm Algorithm
+	Bytes     int // secret size
+}
+
+// what authenticator apps assume when the URI leaves a parameter out
+func DefaultOptions() Options {
+	return Options{Digits: 6, Period: 30, Algorithm: SHA1, Bytes: DefaultSecretBytes}
+}
+
+func (o Options) Validate() error {
+	switch {
+	case !slices.Contains(Algorithms, o.Algorithm):
+		return fmt.Errorf("unknown algorithm %q (SHA1, SHA256, SHA512)", o.Algorithm)
+	case o.Digits < 6 || o.Digits > 8:
+		return fmt.Errorf("codes need 6-8 digits (got %d)", o.Digits)
+	case o.Period < 1 || o.Period > 3600:
+		return fmt.Errorf("period must be 1-3600 seconds (got %d)", o.Period)
+	case o.Bytes < MinSecretBytes || o.Bytes > MaxSecretBytes:
+		return fmt.Errorf("secrets need %d-%d bytes (got %d)", MinSecretBytes, MaxSecretBytes, o.Bytes)
+	case o.Account == "":
+		return fmt.Errorf("an account name is required")
+	case strings.Contains(o.Issuer, ":") || strings.Contains(o.Account, ":"):
+		return fmt.Errorf("issuer and account can't contain ':', it separates them in the URI")
+	}
+	return nil
+}
+
+// a secret and the parameters it's used with
+type Key struct {
+	Secret []byte
+	Options
+}
+
+// a new secret, 256 bits per draw or 512 for secrets longer than that
+func Generate(collector *entropy.Collector, opts Options) (Key, error) {
+	if err := opts.Validate(); err != nil {
+		return Key{}, err
+	}
+
+	raw := collector.GetRawEntropy()
+	if opts.Bytes > len(raw) {
+		raw = collector.GetRawEntropy512()
+	}
+	return Key{Secret: raw[:opts.Bytes], Options: opts}, nil
+}
+
+// unpadded RFC 4648 base32, the form apps accept for manual entry
+func (k Key) Base32() string {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(k.Secret)
+}
+
+// Google's Key URI format, e.g.
+// otpauth://totp/Issuer:account?secret=...&issuer=Issuer&algorithm=SHA1&digits=6&period=30
+// spaces are escaped as %20, some apps show a + literally
+func (k Key) URI() string {
+	label := url.PathEscape(k.Account)
+	if k.Issuer != "" {
+		label = url.PathEscape(k.Issuer) + ":" + label
+	}
+
+	params := []string{"secret=" + k.Base32()}
+	if k.Issuer != "" {
+		params = append(params, "issuer="+queryEscape(k.Issuer))
+	}
+	params = append(params,
+		"algorithm="+string(k.Algorithm),
+		fmt.Sprintf("digits=%d", k.Digits),
+		fmt.Sprintf("period=%d", k.Period))
+
+	return "otpauth://totp/" + label + "?" + strings.Join(params, "&")
+}
+
+// & = and + escaped too, with %20 for spaces like the label
+func queryEscape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+// the code valid at t
+func (k Key) Code(t time.Time) string {
+	return HOTP(k.Secret, uint64(t.Unix())/uint64(k.Period), k.Digits, k.Algorithm)
+}
+
+// seconds until the code valid at t changes
+func (k Key) Remaining(t time.Time) int {
+	return k.Period - int(t.Unix()%int64(k.Period))
+}
+
+// RFC 4226, an HMAC of the counter cut down to a number by dynamic
+// truncation
+func HOTP(secret []byte, counter uint64, digits int, algorithm Algorithm) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], counter)
+
+	mac := hmac.New(hashFor(algorithm), secret)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulus := uint32(1)
+	for range digits {
+		modulus *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%modulus)
+}
+
+func hashFor(algorithm Algorithm) func() hash.Hash {
+	switch algorithm {
+	case SHA256:
+		return sha256.New
+	case SHA512:
+		return sha512.New
+	}
+	return sha1.New
+}
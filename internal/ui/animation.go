@@ -4,6 +4,7 @@ import (
 	"math/rand"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"datflux/internal/password"
 
@@ -119,11 +120,12 @@ func (pa *PasswordAnimation) StartAnimation(password string) {
 	pa.IsAnimating = true
 	pa.Progress = 0
 	pa.ColorPhase = 0
-	pa.Current = strings.Repeat("?", len(password))
+	// runes, not bytes, so non-ASCII passphrases reveal cleanly
+	pa.Current = strings.Repeat("?", utf8.RuneCountInString(password))
 	pa.lastUpdateTime = time.Now()
 
 	// adjust for paranoia mode
-	if pa.ParanoiaMode && len(password) > 40 {
+	if pa.ParanoiaMode && utf8.RuneCountInString(password) > 40 {
 		// Faster animation
 		pa.FlickersPerChar = 2           // fewer flickers per char
 		pa.Delay = time.Millisecond * 20 // halve delay
@@ -148,7 +150,8 @@ func (pa *PasswordAnimation) Update() bool {
 	charPos := pa.Progress / pa.FlickersPerChar
 	flickerPos := pa.Progress % pa.FlickersPerChar
 
-	if charPos >= len(pa.Target) {
+	targetRunes := []rune(pa.Target)
+	if charPos >= len(targetRunes) {
 		pa.Current = pa.Target
 		pa.IsAnimating = false
 		return true
@@ -157,7 +160,7 @@ func (pa *PasswordAnimation) Update() bool {
 	currentRunes := []rune(pa.Current)
 
 	if flickerPos == pa.FlickersPerChar-1 {
-		currentRunes[charPos] = []rune(pa.Target)[charPos]
+		currentRunes[charPos] = targetRunes[charPos]
 	} else {
 		currentRunes[charPos] = rune(pa.Generator.GenerateRandomChar())
 	}
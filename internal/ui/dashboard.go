@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"os/exec"
+	"slices"
 	"strings"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"datflux/internal/entropy"
 	"datflux/internal/monitor"
 	"datflux/internal/password"
+	"datflux/internal/qr"
 )
 
 type tickMsg time.Time
@@ -21,19 +23,21 @@ type clipboardResultMsg struct {
 	message string
 }
 
-type clipboardClearMsg struct{}
+type statusClearMsg struct{}
 
 type Dashboard struct {
 	systemMonitor      *monitor.SystemMonitor
 	passwordGen        *password.Generator
-	currentAttackModel password.AttackModelType
+	analyzer           password.Analyzer
+	attackModels       []password.AttackModel
+	currentAttackModel int
 	entropyCollector   *entropy.Collector
 	animation          *PasswordAnimation
 	width              int
 	height             int
 	ready              bool
 	lastPassword       string
-	clipboardStatus    string
+	statusMessage      string
 	cpuProgress        progress.Model
 	memProgress        progress.Model
 	themeManager       *ThemeManager
@@ -41,17 +45,27 @@ type Dashboard struct {
 	regularTheme       ThemeType
 	paranoiaMode       bool
 	paranoiaTheme      Theme
+	profileBaseline    *profileBaseline
+	wifiSSID           string
+	qrOverlay          *qrOverlay
 }
 
-func NewDashboardModel(collector *entropy.Collector) *Dashboard {
+// settings in use before the first profile was picked, restored by "none"
+type profileBaseline struct {
+	policy   password.Policy
+	mode     password.Mode
+	template *password.Template
+}
+
+// the analyzer judges every password shown, it should cache its results
+// since the view asks again on every frame
+func NewDashboardModel(collector *entropy.Collector, passGen *password.Generator, analyzer password.Analyzer) *Dashboard {
 	themeManager := NewThemeManager()
 
 	InitializeStyles(themeManager.GetCurrentTheme())
 
 	sysMonitor := monitor.NewSystemMonitor()
 
-	passGen := password.NewGenerator(collector)
-
 	passGen.SetParanoiaMode(false, 25)
 
 	anim := NewPasswordAnimation(passGen)
@@ -61,30 +75,206 @@ func NewDashboardModel(collector *entropy.Collector) *Dashboard {
 	memBar := MemoryProgress
 
 	return &Dashboard{
-		systemMonitor:      sysMonitor,
-		passwordGen:        passGen,
-		entropyCollector:   collector,
-		animation:          anim,
-		ready:              false,
-		cpuProgress:        cpuBar,
-		memProgress:        memBar,
-		themeManager:       themeManager,
-		currentTheme:       themeManager.currentTheme,
-		regularTheme:       themeManager.currentTheme,
-		paranoiaMode:       false,
-		paranoiaTheme:      createMidnightAblazeTheme(),
-		currentAttackModel: password.OnlineRateLimited,
+		systemMonitor:    sysMonitor,
+		passwordGen:      passGen,
+		analyzer:         analyzer,
+		entropyCollector: collector,
+		animation:        anim,
+		ready:            false,
+		cpuProgress:      cpuBar,
+		memProgress:      memBar,
+		themeManager:     themeManager,
+		currentTheme:     themeManager.currentTheme,
+		regularTheme:     themeManager.currentTheme,
+		paranoiaMode:     false,
+		paranoiaTheme:    createMidnightAblazeTheme(),
+		attackModels:     password.GetAttackModels(),
 	}
 }
 
-// method to toggle paranoia mode
-func (d *Dashboard) ToggleParanoiaMode() {
+// applies a password policy to standard mode generation
+func (d *Dashboard) SetPolicy(policy password.Policy) error {
+	if err := d.passwordGen.SetPolicy(policy); err != nil {
+		return err
+	}
+
+	d.lastPassword = ""
+	d.animation.Current = "Press 'r' to generate"
+	d.animation.Target = ""
+	return nil
+}
+
+// models [o] cycles through, e.g. from password.LoadAttackModels
+func (d *Dashboard) SetAttackModels(models []password.AttackModel) {
+	if len(models) == 0 {
+		models = password.GetAttackModels()
+	}
+	d.attackModels = models
+	d.currentAttackModel = 0
+}
+
+func (d *Dashboard) SetPassphraseOptions(opts password.PassphraseOptions) error {
+	return d.passwordGen.SetPassphraseOptions(opts)
+}
+
+func (d *Dashboard) SetPronounceableOptions(opts password.PronounceableOptions) error {
+	return d.passwordGen.SetPronounceableOptions(opts)
+}
+
+func (d *Dashboard) SetTemplate(template *password.Template) {
+	d.passwordGen.SetTemplate(template)
+}
+
+func (d *Dashboard) SetRegex(regex *password.Regex) {
+	d.passwordGen.SetRegex(regex)
+}
+
+func (d *Dashboard) SetMode(mode password.Mode) {
+	d.passwordGen.SetMode(mode)
+
+	d.lastPassword = ""
+	d.animation.Current = "Press 'r' to generate"
+	d.animation.Target = ""
+}
+
+func (d *Dashboard) SetParanoiaOptions(opts password.ParanoiaOptions) error {
+	return d.passwordGen.SetParanoiaOptions(opts)
+}
+
+func (d *Dashboard) SetWordlist(wordlist *password.Wordlist) {
+	d.passwordGen.SetWordlist(wordlist)
+}
+
+// moves to the next available wordlist, skipping ones that fail to load
+func (d *Dashboard) CycleWordlist() tea.Cmd {
 	if d.animation.IsAnimating {
+		return nil
+	}
+
+	names := password.AvailableWordlists()
+	current := slices.Index(names, d.passwordGen.GetWordlist().Name)
+
+	for offset := 1; offset < len(names); offset++ {
+		name := names[(current+offset)%len(names)]
+
+		if wordlist, err := password.FindWordlist(name); err == nil {
+			d.SetWordlist(wordlist)
+			return d.flashStatus(fmt.Sprintf("Wordlist: %s (%.2f bits/word)",
+				name, wordlist.EntropyPerWord()))
+		}
+	}
+
+	return d.flashStatus("No other usable wordlist in " + password.WordlistDir())
+}
+
+// switches to a profile's policy or template, remembering what it replaced
+func (d *Dashboard) ApplyProfile(profile password.Profile) error {
+	baseline := profileBaseline{
+		policy:   d.passwordGen.GetPolicy(),
+		mode:     d.passwordGen.GetMode(),
+		template: d.passwordGen.GetTemplate(),
+	}
+
+	if err := d.passwordGen.ApplyProfile(profile); err != nil {
+		return err
+	}
+	if d.profileBaseline == nil {
+		d.profileBaseline = &baseline
+	}
+
+	d.SetMode(profile.Mode())
+	return nil
+}
+
+// back to the settings from before the first profile, the profile stays
+// applied when the generator refuses the old policy
+func (d *Dashboard) clearProfile() error {
+	if d.profileBaseline == nil {
+		d.passwordGen.ClearProfile()
+		return nil
+	}
+
+	if err := d.passwordGen.LeaveProfile(d.profileBaseline.policy); err != nil {
+		return err
+	}
+	d.passwordGen.SetTemplate(d.profileBaseline.template)
+	d.SetMode(d.profileBaseline.mode)
+	d.profileBaseline = nil
+	return nil
+}
+
+// moves to the next profile in profiles.conf, then back to none
+// template profiles are skipped in paranoia mode, broken ones always
+func (d *Dashboard) CycleProfile() tea.Cmd {
+	if d.animation.IsAnimating {
+		return nil
+	}
+
+	profiles, err := password.LoadProfiles(password.ProfilesPath())
+	if err != nil {
+		return d.flashStatus(err.Error())
+	}
+	if len(profiles) == 0 {
+		return d.flashStatus("No profiles in " + password.ProfilesPath())
+	}
+
+	current := -1
+	for i, profile := range profiles {
+		if profile.Name == d.passwordGen.GetProfile() {
+			current = i
+		}
+	}
+
+	for next := current + 1; next < len(profiles); next++ {
+		profile := profiles[next]
+		if d.paranoiaMode && profile.Mode() != password.ModeCharacters {
+			continue
+		}
+		if err := d.ApplyProfile(profile); err == nil {
+			return d.flashStatus("Profile: " + profile.Name)
+		}
+	}
+
+	if err := d.clearProfile(); err != nil {
+		return d.flashStatus(fmt.Sprintf("Profile %s kept: %v", d.passwordGen.GetProfile(), err))
+	}
+	return d.flashStatus("Profile: none")
+}
+
+// switches between character passwords and the given mode
+// paranoia mode is character-only, so no switching there
+func (d *Dashboard) ToggleMode(mode password.Mode) {
+	if d.animation.IsAnimating || d.paranoiaMode {
 		return
 	}
 
+	// leaving the profile's mode leaves the profile, its settings stay
+	d.passwordGen.ClearProfile()
+	d.profileBaseline = nil
+
+	if d.passwordGen.GetMode() == mode {
+		d.SetMode(password.ModeCharacters)
+	} else {
+		d.SetMode(mode)
+	}
+}
+
+// method to toggle paranoia mode
+// fails to enable when the policy has no password in the paranoia lengths
+func (d *Dashboard) ToggleParanoiaMode() tea.Cmd {
+	if d.animation.IsAnimating {
+		return nil
+	}
+
+	if err := d.passwordGen.SetParanoiaMode(!d.paranoiaMode, d.passwordGen.GetParanoiaOptions().Candidates); err != nil {
+		return d.flashStatus(err.Error())
+	}
 	d.paranoiaMode = !d.paranoiaMode
-	d.passwordGen.SetParanoiaMode(d.paranoiaMode, 25)
+	if d.paranoiaMode && d.passwordGen.GetMode() != password.ModeCharacters {
+		d.passwordGen.ClearProfile()
+		d.profileBaseline = nil
+		d.passwordGen.SetMode(password.ModeCharacters)
+	}
 	d.animation.ParanoiaMode = d.paranoiaMode
 
 	// clear password when toggling modes
@@ -111,6 +301,17 @@ func (d *Dashboard) ToggleParanoiaMode() {
 		d.cpuProgress = CPUProgress
 		d.memProgress = MemoryProgress
 	}
+	return nil
+}
+
+// shows a message in place of the help line for a few seconds
+func (d *Dashboard) flashStatus(message string) tea.Cmd {
+	d.statusMessage = message
+	return tea.Sequence(
+		tea.Tick(3*time.Second, func(time.Time) tea.Msg {
+			return statusClearMsg{}
+		}),
+	)
 }
 
 func (d *Dashboard) Init() tea.Cmd {
@@ -183,8 +384,44 @@ func (d *Dashboard) SwitchTheme() {
 	d.memProgress = MemoryProgress
 }
 
+// QR codes become Wi-Fi networks to join instead of bare passwords
+func (d *Dashboard) SetWiFiSSID(ssid string) error {
+	if err := qr.ValidateSSID(ssid); err != nil {
+		return err
+	}
+	d.wifiSSID = ssid
+	return nil
+}
+
+// opens the last password as a QR code, as a WPA network when an SSID is
+// set and the password can be its passphrase
+func (d *Dashboard) ShowQRCode() tea.Cmd {
+	if d.lastPassword == "" {
+		return d.flashStatus("Generate a password first")
+	}
+
+	overlay := &qrOverlay{title: "Password"}
+	payload := d.lastPassword
+	if d.wifiSSID != "" {
+		if wifi, err := qr.WiFi(d.wifiSSID, d.lastPassword); err != nil {
+			overlay.note = fmt.Sprintf("Not a Wi-Fi code: %v", err)
+		} else {
+			overlay.title = "Wi-Fi " + d.wifiSSID
+			payload = wifi
+		}
+	}
+
+	code, err := qr.Encode([]byte(payload), qr.Medium)
+	if err != nil {
+		return d.flashStatus(err.Error())
+	}
+	overlay.code = code
+	d.qrOverlay = overlay
+	return nil
+}
+
 func (d *Dashboard) CycleAttackModel() {
-	d.currentAttackModel = (d.currentAttackModel + 1) % 3
+	d.currentAttackModel = (d.currentAttackModel + 1) % len(d.attackModels)
 }
 
 func (d *Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -230,25 +467,34 @@ func (d *Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return d, tea.Batch(cmds...)
 
 	case clipboardResultMsg:
-		d.clipboardStatus = msg.message
-		return d, tea.Sequence(
-			tea.Tick(3*time.Second, func(time.Time) tea.Msg {
-				return clipboardClearMsg{}
-			}),
-		)
-
-	case clipboardClearMsg:
-		d.clipboardStatus = ""
+		return d, d.flashStatus(msg.message)
+
+	case statusClearMsg:
+		d.statusMessage = ""
 		return d, nil
 
 	case tea.KeyMsg:
+		// the overlay takes every key until it's closed
+		if d.qrOverlay != nil {
+			switch msg.String() {
+			case "ctrl+c":
+				return d, tea.Quit
+			case "v", "q", "esc", "enter":
+				d.qrOverlay = nil
+			}
+			return d, nil
+		}
+
 		switch msg.String() {
 		case "q", "ctrl+c", "esc":
 			return d, tea.Quit
 
 		case "r":
 			if !d.animation.IsAnimating {
-				newPassword := d.passwordGen.Generate()
+				newPassword, err := d.passwordGen.Generate()
+				if err != nil {
+					return d, d.flashStatus(err.Error())
+				}
 				d.lastPassword = newPassword
 				d.animation.StartAnimation(newPassword)
 			}
@@ -260,6 +506,9 @@ func (d *Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return d, nil
 
+		case "v":
+			return d, d.ShowQRCode()
+
 		case "t":
 			d.SwitchTheme()
 			return d, nil
@@ -270,9 +519,23 @@ func (d *Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case "p":
 			if !d.animation.IsAnimating {
-				d.ToggleParanoiaMode()
+				return d, d.ToggleParanoiaMode()
 			}
 			return d, nil
+
+		case "w":
+			d.ToggleMode(password.ModePassphrase)
+			return d, nil
+
+		case "s":
+			d.ToggleMode(password.ModePronounceable)
+			return d, nil
+
+		case "l":
+			return d, d.CycleWordlist()
+
+		case "f":
+			return d, d.CycleProfile()
 		}
 	}
 
@@ -284,6 +547,10 @@ func (d *Dashboard) View() string {
 		return "Initializing datFlux..."
 	}
 
+	if d.qrOverlay != nil {
+		return renderQROverlay(d.qrOverlay, d.width, d.height)
+	}
+
 	if d.width < MinScreenWidth || d.height < MinScreenHeight {
 
 		warningText := fmt.Sprintf(
@@ -314,7 +581,8 @@ func (d *Dashboard) View() string {
 		d.entropyCollector.GetEntropyQuality(),
 		panelWidth,
 		d.passwordGen,
-		d.currentAttackModel,
+		d.analyzer,
+		d.attackModels[d.currentAttackModel],
 	)
 
 	cpuView := renderCPUView(
@@ -348,10 +616,13 @@ func (d *Dashboard) View() string {
 	)
 
 	var helpText string
-	if d.clipboardStatus != "" {
-		helpText = ValueStyle.Render(d.clipboardStatus)
+	if d.statusMessage != "" {
+		helpText = ValueStyle.Render(d.statusMessage)
 	} else {
-		helpText = HelpStyle.Render("[r] ⟳ gen | [c] ⎘ copy | [o] model | [t] theme | [p] paranoia | [q] quit")
+		helpText = renderHelp([]string{
+			"[r] ⟳ gen", "[c] ⎘ copy", "[v] ▦ qr", "[w] words", "[s] syllables", "[l] list",
+			"[f] profile", "[o] model", "[t] theme", "[p] paranoia", "[q] quit",
+		}, contentWidth)
 	}
 
 	return docStyle.Render(
@@ -0,0 +1,147 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"datflux/internal/qr"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// black on white whatever the terminal's own colors, scanners expect dark
+// modules on a light background; colors are detected on out since the
+// code often goes to stderr while stdout is piped
+func QRCode(code *qr.Code, out io.Writer) string {
+	style := lipgloss.NewRenderer(out).NewStyle().
+		Foreground(lipgloss.Color("#000000")).
+		Background(lipgloss.Color("#FFFFFF"))
+	return style.Render(strings.Join(code.HalfBlocks(qr.QuietZone, 1), "\n"))
+}
+
+// a QR code shown over the dashboard
+type qrOverlay struct {
+	code  *qr.Code
+	title string
+	note  string // why the code isn't what was asked for
+}
+
+// 7:1 is WCAG's AAA contrast, phone cameras read it in dim light too
+const qrMinContrast = 7
+
+type rgb [3]float64
+
+func parseHexColor(color lipgloss.Color) (rgb, bool) {
+	hex := strings.TrimPrefix(string(color), "#")
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return rgb{}, false
+	}
+	return rgb{float64(value >> 16), float64(value >> 8 & 0xff), float64(value & 0xff)}, true
+}
+
+// WCAG relative luminance
+func (c rgb) luminance() float64 {
+	var linear [3]float64
+	for i, channel := range c {
+		channel /= 255
+		if channel <= 0.03928 {
+			linear[i] = channel / 12.92
+		} else {
+			linear[i] = math.Pow((channel+0.055)/1.055, 2.4)
+		}
+	}
+	return 0.2126*linear[0] + 0.7152*linear[1] + 0.0722*linear[2]
+}
+
+// moves the color a fraction of the way to a gray level, 0 black 255 white
+func (c rgb) toward(level, fraction float64) rgb {
+	for i := range c {
+		c[i] += (level - c[i]) * fraction
+	}
+	return c
+}
+
+func (c rgb) color() lipgloss.Color {
+	return lipgloss.Color(fmt.Sprintf("#%02x%02x%02x",
+		int(math.Round(c[0])), int(math.Round(c[1])), int(math.Round(c[2]))))
+}
+
+func contrast(light, dark rgb) float64 {
+	return (light.luminance() + 0.05) / (dark.luminance() + 0.05)
+}
+
+// the theme's darkest color for the modules and its lightest behind them,
+// pushed toward black and white until they contrast enough to scan
+func qrColors(theme Theme) (lipgloss.Color, lipgloss.Color) {
+	dark, light := rgb{0, 0, 0}, rgb{255, 255, 255}
+	found := false
+	for _, color := range []lipgloss.Color{
+		theme.Primary, theme.Secondary, theme.Accent, theme.Highlight, theme.Warning,
+		theme.Danger, theme.PasswordColor, theme.StrongColor, theme.VeryStrongColor,
+	} {
+		c, ok := parseHexColor(color)
+		switch {
+		case !ok:
+			continue
+		case !found:
+			dark, light, found = c, c, true
+		case c.luminance() < dark.luminance():
+			dark = c
+		case c.luminance() > light.luminance():
+			light = c
+		}
+	}
+
+	for range 20 {
+		if contrast(light, dark) >= qrMinContrast {
+			break
+		}
+		dark = dark.toward(0, 0.2)
+		light = light.toward(255, 0.2)
+	}
+	return dark.color(), light.color()
+}
+
+// the code scaled as large as the space allows, the quiet zone shrinks
+// before giving up since scanners rarely need all four modules of it
+func fitQRCode(code *qr.Code, width, height int) (string, bool) {
+	for _, quiet := range []int{qr.QuietZone, 2} {
+		for scale := 8; scale >= 1; scale-- {
+			w, h := code.RenderedSize(quiet, scale)
+			if w > width || h > height {
+				continue
+			}
+			dark, light := qrColors(currentTheme)
+			style := lipgloss.NewStyle().Foreground(dark).Background(light)
+			return style.Render(strings.Join(code.HalfBlocks(quiet, scale), "\n")), true
+		}
+	}
+	return "", false
+}
+
+func renderQROverlay(overlay *qrOverlay, width, height int) string {
+	parts := []string{SectionTitleStyle.Render(" " + overlay.title + " "), ""}
+	reserved := 4 // title, blank lines, help
+	if overlay.note != "" {
+		reserved++
+	}
+
+	if code, ok := fitQRCode(overlay.code, width, height-reserved); ok {
+		parts = append(parts, code)
+	} else {
+		w, h := overlay.code.RenderedSize(2, 1)
+		parts = append(parts, WarningStyle.Render(fmt.Sprintf(
+			"Terminal too small for this QR code, it needs %dx%d", w, h+reserved)))
+	}
+	if overlay.note != "" {
+		parts = append(parts, WarningStyle.Render(overlay.note))
+	}
+	parts = append(parts, "", HelpStyle.Render("[v] [esc] close"))
+
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center,
+		lipgloss.JoinVertical(lipgloss.Center, parts...))
+}
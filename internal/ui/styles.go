@@ -167,6 +167,131 @@ dF== == == == == == ==| v1.0.0 |== == == == == == == == == == == == == == == ==
 	return LogoStyle.Render(strings.TrimRight(logo, "\n"))
 }
 
+// extended options, printed below the logo
+func Usage() string {
+	usage := `
+  POLICY OPTIONS (datflux now, or datflux [options] for the TUI):
+    --length, -l N          Exact password length
+    --min N, --max N        Length range (default 16-32)
+    --no-symbols            Exclude symbols
+    --no-digits             Exclude digits
+    --no-upper              Exclude uppercase letters
+    --no-lower              Exclude lowercase letters
+    --charset NAME          Preset (alnum, no-ambiguous, shell-safe, url-safe,
+                            xml-safe, hex, base32)
+    --chars CHARS           Literal alphabet instead of a preset
+    --exclude CHARS         Never use these characters
+    --pwquality PATH        Also satisfy a pwquality.conf (minlen, credits,
+                            minclass, maxrepeat, maxclassrepeat)
+    --start-letter          First character must be a letter
+    --max-repeat N          No more than N identical characters in a row
+    --max-sequence N        No abcd / 4321 / qwer runs longer than N
+    --max-occurrences N     No character more than N times
+
+  PASSPHRASE OPTIONS (EFF large wordlist, ~12.9 bits per word):
+    --words, -w N           Generate an N-word passphrase instead
+    --separator SEP         Word separator (default "-")
+    --capitalize            Capitalise every word
+    --digit                 Insert a random digit into one word
+    --wordlist NAME         Wordlist from the config dir (datflux wordlists)
+
+  PRONOUNCEABLE OPTIONS (exact entropy, not zxcvbn's estimate):
+    --syllables N           Generate a pronounceable password of N syllables
+    --suffix-digits N       Append N random digits
+    --capitalize            Capitalise one random syllable
+
+  TEMPLATE OPTIONS (exact entropy per position):
+    --pattern TEMPLATE      e.g. 'Cvcc-9999-!!' or 'X{4}-X{4}-X{4}'
+                            a/A letter  c/C consonant  v/V vowel  x/X alnum
+                            h/H hex  9 digit  ! symbol  * any  [abc] set
+                            {n} repeat  \ escape, anything else is literal
+    --save-profile NAME     Save --pattern under NAME (datflux now)
+
+  PROFILES (~/.config/datflux/profiles.conf, [f] cycles them in the TUI):
+    --profile NAME          Use a saved pattern or per-site policy
+                            keys: pattern, length, min, max, require,
+                            forbid, max_repeat, charset, chars,
+                            user_inputs, dictionary
+
+  USER DICTIONARIES (TUI and check, words zxcvbn tries first):
+    --user-inputs LIST      Comma-separated names, sites or words
+    --user-dict PATH        File of words, one per line
+
+  REGEX OPTIONS (uniform over every match, printable ASCII only):
+    --regex EXPR            e.g. '[A-Z][a-z]{5,8}[0-9]{2}[!@#$]'
+                            no * or +, every repeat needs an upper bound
+
+    --mode MODE             characters, passphrase, pronounceable, template
+                            or regex
+
+  PARANOIA OPTIONS (datflux now -p, or [p] in the TUI):
+    --candidates N          Keep the best of N by zxcvbn (default 5 CLI,
+                            25 TUI), 1 disables the selection
+    --paranoia-length R     Length or range (default 48-80)
+    --shuffle-passes N      Fisher-Yates passes (default 3)
+
+  CHECK (datflux check [FILE], one password per line, prompts if interactive):
+    --pwquality PATH        Rules to check against
+                            (default /etc/security/pwquality.conf)
+    --profile NAME          Also check a profile's policy (or policy flags)
+    --min-score N           Fail passwords zxcvbn scores below N (0-4)
+    --json                  Print a JSON report instead of a table
+    --show                  Include the passwords in the report
+                            exit 0 all pass, 1 any fail, 2 error
+
+  BLACKLIST (now, TUI and check; leetspeak and reversed forms match too):
+    --blacklist PATHS       Comma-separated wordlists, e.g.
+                            /usr/share/dict/words,banned.txt
+                            generated passwords are redrawn on a match
+
+  BREACHES (offline Have I Been Pwned SHA-1 lists, TUI and check):
+    datflux breach index SOURCE...
+                            Index the sorted dump or a range file directory
+    --out PATH              Index location (default ~/.config/datflux/hibp.idx)
+    --breach-index PATH     Index to check against (default one is used
+                            when present)
+
+  TOKENS (datflux token, API keys and secrets, one per line on stdout):
+    --bytes N               Random bytes (default 32, 16-1024)
+    --format FORMAT         hex, base64, base64url, base32, uuid or ulid
+    --prefix TEXT           Prepended as is, e.g. dfx_live_
+    --checksum              Append a CRC32 for secret scanners
+    --count N               Number of tokens (default 1)
+
+  KEYS (datflux keygen ssh|wireguard|age, seeded from the entropy pool):
+    --out PATH              Private key file, created 0600 (public: PATH.pub)
+    --comment TEXT          SSH key comment (default user@host)
+    --passphrase            Encrypt the SSH key, prompts or reads stdin
+    --force                 Overwrite existing key files
+
+  TOTP (datflux totp, authenticator secret as a QR code, URI on stdout):
+    --issuer NAME           Service name shown in the app
+    --account NAME          Account name (default user@host)
+    --digits N              Code length, 6-8 (default 6)
+    --period N              Seconds per code (default 30)
+    --algorithm NAME        SHA1, SHA256 or SHA512 (default SHA1)
+    --bytes N               Secret size (default 20, 16-64)
+
+  QR CODES ([v] in the TUI shows the last password as a QR code):
+    --wifi-ssid NAME        Show it as a WPA network to join instead
+
+  ATTACK MODELS (~/.config/datflux/attack-models.conf, [o] cycles them):
+    datflux models          List every model and its guess rate
+    datflux models import FILE
+                            Per-GPU rates from hashcat --benchmark output
+    --attack-models PATH    Model config for the TUI, check and models
+                            keys: hash (md5, ntlm, sha512crypt, bcrypt,
+                            argon2id), cost, rounds, memory, iterations,
+                            parallelism, gpus, rate, description,
+                            gpu_hour, gpu_watts ([prices] for all models)
+                            logical_hz, iteration_depth, machines,
+                            target_years (quantum, [quantum] for built-in)
+    --quantum-years N       Years passwords should resist Grover's search
+                            (default 100), sets the recommended length
+`
+	return HelpStyle.Render(strings.Trim(usage, "\n"))
+}
+
 func Wiper() {
 	var cmd *exec.Cmd
 	if runtime.GOOS == "windows" {
@@ -157,7 +157,7 @@ func renderNetworkView(rxSpeed float64, txSpeed float64, iface string, width int
 	return BorderStyle.Width(width).Render(builder.String())
 }
 
-func renderPasswordView(animation *PasswordAnimation, quality float64, width int, passwordGen *password.Generator, attackModel password.AttackModelType) string {
+func renderPasswordView(animation *PasswordAnimation, quality float64, width int, passwordGen *password.Generator, analyzer password.Analyzer, attackModel password.AttackModel) string {
 	var builder strings.Builder
 
 	// title := "CRYPTOGRAPHICALLY SECURED PASSWORD"
@@ -176,7 +176,7 @@ func renderPasswordView(animation *PasswordAnimation, quality float64, width int
 	// }
 
 	passwordText := animation.CurrentPassword()
-	passLen := len(passwordText)
+	passLen := lipgloss.Width(passwordText)
 	padding := max((width-passLen-4)/2, 0)
 
 	builder.WriteString(strings.Repeat(" ", padding))
@@ -187,33 +187,82 @@ func renderPasswordView(animation *PasswordAnimation, quality float64, width int
 		builder.WriteString(PasswordStyle.Render(passwordText))
 
 		if passwordText != "Press 'r' to generate" && len(passwordText) > 0 {
-			strength := passwordGen.AnalyzeStrength(passwordText)
+			strength := analyzer.Analyze(passwordText)
 
 			builder.WriteString("\n\n")
 
 			// strength meter
 			builder.WriteString(renderStrengthMeter(strength.Score, width-10))
 
+			paranoiaMode, _ := passwordGen.GetParanoiaMode()
+			exact := strength.Exact
+			exactBits, hasExact := exact.Bits, exact.Known
+
 			// length and entropy stats
-			statsText := fmt.Sprintf("Length: %d | Entropy: %.1f bits", len(passwordText), strength.EntropyBits)
+			var statsText string
+			if hasExact {
+				statsText = fmt.Sprintf("Length: %d | Exact: %.1f bits | zxcvbn: %.1f bits",
+					lipgloss.Width(passwordText), exactBits, strength.EntropyBits)
+			} else {
+				theoretical := passwordGen.GetPolicy().TheoreticalEntropy(len(passwordText))
+				statsText = fmt.Sprintf("Length: %d | Entropy: %.1f bits | Theoretical: %.1f bits",
+					len(passwordText), strength.EntropyBits, theoretical)
+			}
 			builder.WriteString("\n" + VeryStrongPwdStyle.Render(statsText))
 
-			// special note for paranoia mode, due to the extreme security level
-			paranoiaMode, _ := passwordGen.GetParanoiaMode()
+			if strength.BreachCount > 0 {
+				builder.WriteString("\n" + DangerStyle.Render(
+					fmt.Sprintf("Found %d times in breached password lists, don't use it", strength.BreachCount)))
+			}
+			if strength.Blacklisted != "" {
+				builder.WriteString("\n" + WarningStyle.Render("Blacklisted: contains "+strength.Blacklisted))
+			}
+			if gap, significant := exact.Divergence(strength.EntropyBits); significant {
+				builder.WriteString("\n" + WarningStyle.Render(renderDivergence(gap)))
+			}
+			if len(strength.UserMatches) > 0 {
+				builder.WriteString("\n" + WarningStyle.Render(renderUserMatches(strength)))
+			}
+
+			// crack time w current model, paranoia passwords included since
+			// their length is configurable
+			// exact entropy wins where known, zxcvbn misjudges those modes
+			var crackSeconds float64
+			if hasExact {
+				crackSeconds = password.CrackSecondsForEntropy(exactBits, attackModel)
+			} else {
+				crackSeconds = password.CrackSecondsForModel(strength, attackModel)
+			}
+			crackTimeText := fmt.Sprintf("Time to crack: %s", password.GetCrackTimeDescription(crackSeconds))
+			builder.WriteString("\n" + renderStrengthText(crackTimeText, strength.Score))
+			if cost, ok := attackModel.Cost(crackSeconds); ok {
+				builder.WriteString("\n" + renderStrengthText(renderAttackCost(cost), strength.Score))
+			}
+
+			// attack model info
+			if attackModel.Quantum != nil {
+				bits := strength.EntropyBits
+				if hasExact {
+					bits = exactBits
+				}
+				builder.WriteString("\n" + renderQuantumMargin(*attackModel.Quantum, bits, passwordGen))
+			}
+
+			rate := attackModel.RateString()
+			if cost, ok := attackModel.Cost(crackSeconds); ok {
+				rate += ", " + password.FormatHashesPerDollar(cost.HashesPerDollar)
+			}
+			modelText := fmt.Sprintf("Attack model: %s (%s)", attackModel.Name, rate)
+			builder.WriteString("\n" + ValueStyle.Render(modelText))
+
 			if paranoiaMode {
-				builder.WriteString("\n" + ValueStyle.Render("Password exceeds quantum-resistant security threshold"))
-				builder.WriteString("\n" + ValueStyle.Render("Time to crack: Beyond any feasible computation"))
+				builder.WriteString("\n" + ValueStyle.Render(renderSelectionCost(passwordGen.GetParanoiaOptions())))
 			} else {
-				// crack time w current model for standard mode
-				crackTimeDesc := passwordGen.GetCrackTimeForModel(passwordText, attackModel)
-				crackTimeText := fmt.Sprintf("Time to crack: %s", crackTimeDesc)
-				builder.WriteString("\n" + renderStrengthText(crackTimeText, strength.Score))
-
-				// attack model info
-				models := password.GetAttackModels()
-				currentModel := models[attackModel]
-				modelText := fmt.Sprintf("Attack model: %s", currentModel.Name)
-				builder.WriteString("\n" + ValueStyle.Render(modelText))
+				builder.WriteString("\n" + ValueStyle.Render(renderGeneratorSettings(passwordGen)))
+			}
+
+			if passwordGen.GetBlacklist() != nil {
+				builder.WriteString("\n" + ValueStyle.Render(renderBlacklistStats(passwordGen.GetBlacklistStats())))
 			}
 
 			// feedback, if any
@@ -232,6 +281,101 @@ func renderPasswordView(animation *PasswordAnimation, quality float64, width int
 	}
 }
 
+// zxcvbn guesses from the password alone, the exact figure knows how it was made
+func renderDivergence(gap float64) string {
+	if gap > 0 {
+		return fmt.Sprintf("zxcvbn overrates this password by %.0f bits, trust the exact figure", gap)
+	}
+	return fmt.Sprintf("zxcvbn underrates this password by %.0f bits, it can't see the generator", -gap)
+}
+
+// the user inputs zxcvbn found and what they cost
+func renderUserMatches(strength password.PasswordStrength) string {
+	return fmt.Sprintf("Contains %s: -%.1f bits", strings.Join(strength.UserMatches, ", "), strength.UserPenalty)
+}
+
+// crack time in money and power, easier to explain than billions of years
+func renderAttackCost(cost password.AttackCost) string {
+	return fmt.Sprintf("Cost to crack: %s, %s", password.FormatDollars(cost.Dollars), password.FormatEnergy(cost.KWh))
+}
+
+// the entropy Grover's search needs to take the target margin, and the
+// length the current settings reach it at
+func renderQuantumMargin(params password.QuantumParams, bits float64, passwordGen *password.Generator) string {
+	required := params.RequiredBits()
+	text := fmt.Sprintf("Quantum-safe for %g years: %.0f bits", params.TargetYears, required)
+	if length, unit, ok := passwordGen.RecommendedLength(required); ok {
+		text += fmt.Sprintf(", %d+ %s", length, unit)
+	}
+
+	if bits < required {
+		return WarningStyle.Render(fmt.Sprintf("%s (this one has %.0f)", text, bits))
+	}
+	return ValueStyle.Render(text)
+}
+
+// how often the blacklist forced a redraw and what that costs the exact figure
+func renderBlacklistStats(stats password.BlacklistStats) string {
+	return fmt.Sprintf("Blacklist: %d of %d draws rejected (-%.2f bits)",
+		stats.Rejected, stats.Drawn, stats.EntropyCost())
+}
+
+// what keeping the best zxcvbn candidate costs in exact entropy
+func renderSelectionCost(opts password.ParanoiaOptions) string {
+	if opts.Candidates == 1 {
+		return fmt.Sprintf("Paranoia: %s", opts)
+	}
+
+	shannon, minEntropy := opts.SelectionCost()
+	return fmt.Sprintf("Paranoia: %s | selection costs ≤%.1f bits (%.1f min-entropy)",
+		opts, shannon, minEntropy)
+}
+
+// one line describing what produced the password
+func renderGeneratorSettings(passwordGen *password.Generator) string {
+	settings := renderModeSettings(passwordGen)
+	if profile := passwordGen.GetProfile(); profile != "" {
+		return fmt.Sprintf("Profile: %s | %s", profile, settings)
+	}
+	return settings
+}
+
+func renderModeSettings(passwordGen *password.Generator) string {
+	switch passwordGen.GetMode() {
+	case password.ModePassphrase:
+		return fmt.Sprintf("Passphrase: %s (%s)", passwordGen.GetPassphraseOptions(), passwordGen.GetWordlist().Name)
+	case password.ModePronounceable:
+		return fmt.Sprintf("Pronounceable: %s", passwordGen.GetPronounceableOptions())
+	case password.ModeTemplate:
+		return fmt.Sprintf("Template: %s", passwordGen.GetTemplate())
+	case password.ModeRegex:
+		return fmt.Sprintf("Regex: %s", passwordGen.GetRegex())
+	default:
+		return fmt.Sprintf("Policy: %s", passwordGen.GetPolicy())
+	}
+}
+
+// key hints joined with " | ", wrapped so they never widen the layout
+func renderHelp(entries []string, width int) string {
+	var lines []string
+	line := ""
+
+	for _, entry := range entries {
+		switch {
+		case line == "":
+			line = entry
+		case lipgloss.Width(line+" | "+entry) > width:
+			lines = append(lines, line)
+			line = entry
+		default:
+			line += " | " + entry
+		}
+	}
+	lines = append(lines, line)
+
+	return HelpStyle.Render(strings.Join(lines, "\n"))
+}
+
 func renderStrengthMeter(score int, width int) string {
 	colors := []lipgloss.Style{
 		DangerStyle,        // 0 - Very Weak
@@ -43,6 +43,7 @@ func main() {
 	logf("-------------------\n")
 	results := testMode(generator, false, 0, *standardSamples, logf)
 	printResults(results, "Standard Mode", logf)
+	printCharDistribution(results, logf)
 
 	// PARANOIA MODE TEST
 	logf("\nPARANOIA MODE TESTING\n")
@@ -51,6 +52,12 @@ func main() {
 	results = testMode(generator, true, *paranoiaCandidates, *paranoiaSamples, logf)
 	printResults(results, "Paranoia Mode", logf)
 
+	// same settings without the best-of-N pick, to see what selection buys and costs
+	logf("\nPARANOIA MODE WITHOUT SELECTION\n")
+	logf("-------------------\n")
+	unselected := testMode(generator, true, 1, *paranoiaSamples, logf)
+	printSelectionComparison(results, unselected, generator.GetParanoiaOptions(), *paranoiaCandidates, logf)
+
 	logf("\nTest completed successfully.\n")
 	fmt.Println("Results saved to", *outputFile)
 }
@@ -66,7 +73,10 @@ type TestResults struct {
 }
 
 func testMode(generator *password.Generator, paranoia bool, samples, count int, logf func(string, ...interface{})) TestResults {
-	generator.SetParanoiaMode(paranoia, samples)
+	if err := generator.SetParanoiaMode(paranoia, samples); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
 	results := TestResults{
 		Passwords:      make([]string, 0, count),
@@ -83,8 +93,12 @@ func testMode(generator *password.Generator, paranoia bool, samples, count int,
 			logf("Progress: %d/%d passwords\n", i, count)
 		}
 
-		pwd := generator.Generate()
-		strength := generator.AnalyzeStrength(pwd)
+		pwd, err := generator.Generate()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating password: %v\n", err)
+			os.Exit(1)
+		}
+		strength := password.ZxcvbnAnalyzer{}.Analyze(pwd)
 		entropyBits := strength.EntropyBits
 
 		results.Passwords = append(results.Passwords, pwd)
@@ -174,6 +188,72 @@ func printResults(results TestResults, label string, logf func(string, ...any))
 	}
 }
 
+// selected and unselected paranoia results side by side, with the
+// entropy the selection costs in theory
+func printSelectionComparison(selected, unselected TestResults, opts password.ParanoiaOptions, candidates int, logf func(string, ...any)) {
+	stats := func(results TestResults) (avg, stdDev float64) {
+		avg = results.TotalEntropy / float64(results.TotalPasswords)
+		for _, entropy := range results.EntropyValues {
+			stdDev += (entropy - avg) * (entropy - avg)
+		}
+		return avg, math.Sqrt(stdDev / float64(results.TotalPasswords))
+	}
+	selectedAvg, selectedStd := stats(selected)
+	unselectedAvg, unselectedStd := stats(unselected)
+
+	logf("\nSelection Comparison (zxcvbn bits):\n")
+	logf("%-20s %14s %14s\n", "", fmt.Sprintf("best of %d", candidates), "no selection")
+	logf("%-20s %14.2f %14.2f\n", "Average Entropy", selectedAvg, unselectedAvg)
+	logf("%-20s %14.2f %14.2f\n", "Minimum Entropy", selected.MinEntropy, unselected.MinEntropy)
+	logf("%-20s %14.2f %14.2f\n", "Maximum Entropy", selected.MaxEntropy, unselected.MaxEntropy)
+	logf("%-20s %14.2f %14.2f\n", "Standard Deviation", selectedStd, unselectedStd)
+
+	opts.Candidates = candidates
+	shannon, minEntropy := opts.SelectionCost()
+	logf("\nzxcvbn gain from selection: %+.2f bits on average\n", selectedAvg-unselectedAvg)
+	logf("Exact entropy lost to selection: up to %.2f bits (Shannon), %.2f bits (min-entropy)\n", shannon, minEntropy)
+}
+
+// chi-square check that characters within each class come out uniformly
+func printCharDistribution(results TestResults, logf func(string, ...any)) {
+	classes := password.DefaultPolicy().Classes()
+
+	counts := make(map[byte]int)
+	for _, pwd := range results.Passwords {
+		for i := 0; i < len(pwd); i++ {
+			counts[pwd[i]]++
+		}
+	}
+
+	logf("\nCharacter Distribution (chi-square, p < 0.001 flags bias):\n")
+	for _, class := range classes {
+		total := 0
+		for i := 0; i < len(class.Chars); i++ {
+			total += counts[class.Chars[i]]
+		}
+		if total == 0 {
+			continue
+		}
+
+		expected := float64(total) / float64(len(class.Chars))
+		var chiSquare float64
+		for i := 0; i < len(class.Chars); i++ {
+			diff := float64(counts[class.Chars[i]]) - expected
+			chiSquare += diff * diff / expected
+		}
+
+		// Wilson-Hilferty approximation of the chi-square upper tail
+		df := float64(len(class.Chars) - 1)
+		z := (math.Cbrt(chiSquare/df) - (1 - 2/(9*df))) / math.Sqrt(2/(9*df))
+
+		verdict := "uniform"
+		if z > 3.09 {
+			verdict = "BIASED"
+		}
+		logf("%s: %d chars, chi2=%.2f (df=%d, z=%.2f) %s\n", class.Name, total, chiSquare, int(df), z, verdict)
+	}
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a